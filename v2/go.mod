@@ -1,5 +1,5 @@
 module github.com/webability-go/xcore/v2
 
-go 1.15
+go 1.20
 
 require golang.org/x/text v0.3.8
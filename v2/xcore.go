@@ -288,6 +288,275 @@
 //
 // The XDatasetCollection type is a simple []DatasetDef with all the implemented methods and should be enough to use for almost all required cases.
 //
+// Shift and Pop return nil when the collection is empty. XDatasetCollection and XDatasetCollectionTS also implement TryShift and TryPop, that return false when the collection is empty,
+// Insert, RemoveAt and Set at an index (that return ErrCollectionIndex when the index is out of the collection), Slice and Clear.
+//
+// 4. JSON:
+//
+// XDataset, XDatasetCollection, XDatasetTS and XDatasetCollectionTS implement json.Marshaler and json.Unmarshaler.
+// The JSON objects become XDataset, the arrays of objects XDatasetCollection, the homogeneous arrays of scalars []string, []int, []float64 or []bool,
+// the integer numbers int and the other numbers float64. The empty arrays become empty XDatasetCollection.
+//
+//	data := &xcore.XDataset{}
+//	err := json.Unmarshal([]byte(`{"clientname": "Fred", "hobbies": [{"name": "Football"}], "scores": [1, 2]}`), data)
+//	scores, _ := data.GetIntCollection("scores")
+//	code, err := json.Marshal(data)
+//
+// NewXDataset applies the same conversions to a map[string]interface{}.
+//
+// 5. XML:
+//
+// A XDataset can be built from the root element of a XML document: the sub elements become keys, the repeated elements become collections,
+// and the attributes are keys with a prefix ("-" by default). The text of an element with attributes or sub elements is the ".text" key. All the values are strings.
+//
+//	<feed version="2.0">
+//	  <item id="1"><title>First news</title></item>
+//	  <item id="2"><title>Second news</title><price currency="USD">12.50</price></item>
+//	</feed>
+//
+//	data, err := xcore.NewXDatasetFromXMLFile("feed.xml", nil)
+//	version, _ := data.GetString("-version")         // 2.0
+//	price, _ := data.GetString("item>1>price>.text") // 12.50
+//
+// GetXML builds the XML document back from the dataset, and XDataset implements xml.Marshaler and xml.Unmarshaler with the DefaultXDatasetXMLOptions.
+//
+//	xmlstr, err := xcore.GetXML(data, "feed", &xcore.XDatasetXMLOptions{AttrPrefix: "-", TextKey: ".text", Indent: "  "})
+//
+// 6. Go structs:
+//
+// NewXDatasetFromStruct builds a XDataset from a struct, and Bind fills a struct with the values of a XDataset (for instance the data of a form).
+// The keys are the names of the tags xcore:"name,omitempty", or the lowercase names of the fields. The tag xcore:"-" ignores the field.
+// The nested structs become XDataset, the slices of structs XDatasetCollection, and the time.Time are preserved.
+// Bind converts the values to the types of the fields when possible (strings to numbers, booleans and RFC 3339 times).
+//
+//	type Client struct {
+//	  Name    string    `xcore:"clientname"`
+//	  Hired   time.Time `xcore:"hired,omitempty"`
+//	  Hobbies []Hobby   `xcore:"hobbies"`
+//	}
+//
+//	data, err := xcore.NewXDatasetFromStruct(client)
+//	result := tmpl.Execute(data)
+//
+//	client2 := &Client{}
+//	err = data.Bind(client2)
+//
+// 7. Database:
+//
+// NewXDatasetCollectionFromRows reads all the records of a *sql.Rows result set into a XDatasetCollection of XDataset, with the column names as keys.
+// The NULL values are nil, the []byte are strings, the integers are int, and the date and time columns are time.Time.
+// XDatasetRows is an iterator that builds a XDataset for each record, without loading the whole result set in memory.
+//
+//	rows, err := db.Query("select id, name, birthdate from client")
+//	defer rows.Close()
+//	clients, err := xcore.NewXDatasetCollectionFromRows(rows)
+//	data := &xcore.XDataset{"clients": clients}
+//
+//	xrows, err := xcore.NewXDatasetRows(rows)
+//	for xrows.Next() {
+//	  client := xrows.Dataset()
+//	}
+//	err = xrows.Err()
+//
+// 8. Paths:
+//
+// Get, Set, Del and Exists accept a path "a>b>c" through the nested datasets and collections, the numeric entries being the indexes of the collections.
+// Set creates the missing intermediate entries as XDataset. SetPath does the same and returns an error if the path cannot be followed;
+// with collections = true, the numeric entries create XDatasetCollection instead of XDataset. The index just after the last entry of a collection adds a new entry.
+// Del on an index of a collection removes the entry from the collection.
+// When the path cannot be followed, Set stores the data under the literal key "a>b>c", as before the paths, so no write is lost.
+// The copies of the datasets (NewXDataset, Clone, Merge...) keep the existing keys containing ">" as literal keys, they do not follow them as paths.
+//
+//	data := &xcore.XDataset{}
+//	data.Set("metadata>salary", 10)                      // {"metadata": {"salary": 10}}
+//	err := data.SetPath("hobbies>0>name", "Chess", true) // {"hobbies": [{"name": "Chess"}]}
+//	if data.Exists("hobbies>0") {
+//	  data.Del("hobbies>0")
+//	}
+//
+// XDatasetCollection and XDatasetCollectionTS also implement SetPath, Del and Exists with paths starting with the index: "0>name".
+//
+// 9. Merge, Diff and Patch:
+//
+// Merge merges deeply another dataset into a XDataset or a XDatasetTS: the nested datasets are merged key by key and the other values are replaced by a copy.
+// The collections are replaced (MergeReplace), appended (MergeAppend) or merged entry by entry with the same value of a key field (MergeByKey).
+// Diff builds the list of changes (add, remove, replace) between two datasets with the paths "a>b>c", Patch applies it, and JSONPatch exports it as a RFC 6902 JSON Patch document.
+//
+//	config := defaults.Clone()
+//	err := config.(*xcore.XDataset).Merge(site, &xcore.XDatasetMergeStrategy{Collections: xcore.MergeByKey, Key: "id"})
+//
+//	changes, err := xcore.Diff(before, after)
+//	err = xcore.Patch(record, changes)
+//	patch, err := changes.JSONPatch()
+//
+// 10. Schema:
+//
+// A XDatasetSchema describes the keys of a dataset: the type of the values (SchemaString, SchemaInt, SchemaFloat, SchemaBool, SchemaTime, SchemaDataset, SchemaCollection),
+// the required keys, the ranges (Min, Max), the regular expressions (Pattern), the allowed values (Enum) and the schemas of the nested datasets and collections.
+// Validate returns all the violations with their paths. Coerce first converts the values to the types of the schema with the rules of GetInt, GetFloat and GetBool ("12" to 12).
+// The integers are converted only from integral values: "12.7" is kept and reported as a violation.
+//
+//	schema := &xcore.XDatasetSchema{
+//	  Fields: map[string]*xcore.XDatasetField{
+//	    "name":    {Type: xcore.SchemaString, Required: true},
+//	    "age":     {Type: xcore.SchemaInt, Min: xcore.Bound(0), Max: xcore.Bound(150)},
+//	    "hobbies": {Type: xcore.SchemaCollection, Schema: hobbySchema},
+//	  },
+//	}
+//	if violations := schema.Coerce(data); violations != nil {
+//	  return violations // violations is an error
+//	}
+//
+// 11. Conversions:
+//
+// The Get* functions of the datasets and the GetData* functions of the collections convert the values with the same rules:
+// the strings are parsed as numbers, booleans or times (with the XDatasetTimeLayouts), the numbers are unix times in seconds for GetTime,
+// the json.Number are numbers, and the booleans are 0/1 for GetInt and GetFloat.
+// The Get*Collection functions convert the slices of other types ([]interface{} for instance) element by element.
+//
+//	data := &xcore.XDataset{"age": "30", "hired": "2020-01-02", "scores": []interface{}{1, "2", 3.5}}
+//	age, _ := data.GetInt("age")                 // 30
+//	hired, _ := data.GetTime("hired")            // 2020-01-02 00:00:00 +0000 UTC
+//	scores, _ := data.GetIntCollection("scores") // [1 2 3]
+//
+// 12. Queries:
+//
+// Query selects all the values that match a query, and QueryOne the first one, on XDataset, XDatasetTS, XDatasetCollection and XDatasetCollectionTS.
+// The entries of the query are separated by ">" like the paths, and may be a key (or an index of a collection), "*" for all the values of a dataset or the entries of a collection,
+// "**" for all the nested values at any depth, or a predicate [field=value], [field!=value] or [field] to filter the datasets.
+//
+//	names := data.Query("hobbies>[sport=yes]>name") // []interface{}{"Football", "Tennis"}
+//	totals := data.Query("orders>**>total")
+//	first, ok := data.QueryOne("hobbies>*>name")
+//
+// The loops of the templates accept a query as data id: @@hobbies>[sport=yes]:hobby@@
+//
+// 13. Operations on collections:
+//
+// XDatasetCollection and XDatasetCollectionTS implement Sort by one or more fields ("-field" for descending order, the values compared as times, numbers or strings),
+// Filter and Map to build new collections, Find and IndexOf with a predicate, GroupBy a field to build a dataset of collections,
+// and the aggregates Sum, Avg, Min, Max and Distinct on a field.
+// Sort, Min and Max order the values by type first (missing, booleans, numbers, times, strings, others), then by value:
+// the numeric strings are strings ("9" is after 30), and NaN is before the other numbers.
+//
+//	clients.Sort("country", "-age")
+//	adults := clients.Filter(func(ds xcore.XDatasetDef) bool { age, _ := ds.GetInt("age"); return age >= 18 })
+//	bycountry := clients.GroupBy("country") // {"MX": [...], "US": [...]}
+//	total := orders.Sum("total")
+//
+// 14. Keyed collections:
+//
+// XDatasetKeyedCollection is an ordered collection with a unique key for each entry, for instance the primary key of a database table.
+// Push and Unshift use the value of the KeyField of the dataset as key (a dataset with an existing key replaces the entry at its position),
+// and SetByKey, GetByKey, DelByKey, KeyAt and Keys work directly with the keys. The entries keep the insertion order for Get and the loops.
+//
+//	clients := xcore.NewXDatasetKeyedCollection("id")
+//	clients.Push(&xcore.XDataset{"id": "ABC", "name": "Alice"})
+//	alice, ok := clients.GetByKey("ABC")
+//	clients.DelByKey("ABC")
+//
+// The paths and queries accept the key in place of the index: data.GetString("clients>ABC>name").
+// The reads and the writes (Set, SetPath, Del) resolve an entry of a path the same way: the key first, then the index if there is no such key.
+// Set on an entry that does not exist adds it with the entry as key. Diff compares the keyed collections key by key, with the keys into the paths.
+//
+// 15. Lazy collections:
+//
+// XDatasetLazyCollection is a read only collection backed by a data source (XDatasetPageFunc) that loads the entries by pages of a given size, on demand.
+// Only the pages in use are kept in memory, so a very big collection (a report of 100k rows from a database) does not need to be loaded before the use.
+// Each reads the entries sequentially with a look-ahead on the next page to know the last entry, and the template loops use it, so Count is never called by the templates.
+//
+//	rows := xcore.NewXDatasetLazyCollection(func(page int, size int) ([]xcore.XDatasetDef, error) {
+//	  return loadRows(page*size, size) // SELECT ... LIMIT size OFFSET page*size
+//	}, 500)
+//	data := &xcore.XDataset{"rows": rows}
+//	report := tmpl.Execute(data)
+//
+// Count reads all the pages the first time it is called, and Err returns the last error of the data source (a page with more than size entries is an error too).
+// GetData reads the entries from the first one up to the first entry with the key, without counting the collection.
+//
+// 16. Snapshots:
+//
+// XDatasetSnapshot is an immutable deep copy of a dataset: it can be read by any quantity of goroutines without locks.
+// Set and Del do nothing on a snapshot; With and Without build a new version that shares all the values out of the modified path (structural sharing),
+// and Mutable builds a mutable XDataset copy.
+//
+// XDatasetAtomic holds the current snapshot and swaps it atomically: the readers call Load without lock and always get a consistent version,
+// the writers (Update, Set, Del, Store) are serialized and build the new version from the current one.
+//
+//	config := xcore.NewXDatasetAtomic(data)
+//	// readers, from any goroutine
+//	host, _ := config.Load().GetString("db>host")
+//	// writers
+//	config.Set("db>host", "remote")
+//	config.Update(func(s *xcore.XDatasetSnapshot) (*xcore.XDatasetSnapshot, error) { return s.With("version", 2) })
+//
+// The {{.counter}} and {{.key}} pseudo fields are available into the loops on the collections of the snapshots: the loop provides them without modifying the entries.
+//
+// 17. Observers:
+//
+// XDatasetObservable is a thread safe encapsulator of a dataset that notifies its changes to the listeners, with Subscribe (a function) or SubscribeChan (a channel).
+// Each Set, SetPath and Del emits a XDatasetChange with the operation (ChangeAdd, ChangeReplace or ChangeRemove), the path, the old value and the new value.
+// As XDataset.Set, Set uses the literal key when the path cannot be followed; SetPath returns the error without any change.
+// The changes are delivered in the order they are applied, to all the listeners in the order of subscription, and a listener may modify the dataset.
+//
+//	config := xcore.NewXDatasetObservable(data)
+//	unsubscribe := config.Subscribe(func(change xcore.XDatasetChange) {
+//	  if change.Path == "db>host" {
+//	    reconnect(change.Value)
+//	  }
+//	})
+//	config.Set("db>host", "remote") // calls the listener
+//	unsubscribe()
+//
+// The changes made directly into the nested datasets (obtained with GetDataset) are not observed: use the paths to modify them.
+//
+// 18. Pretty print and hash:
+//
+// PrettyPrint (or the Pretty method of the datasets and collections) dumps the full nested content into an indented readable string, with the keys sorted and the collections in index order,
+// so the same content always gives the same dump, whatever the types (XDataset or XDatasetTS, XDatasetCollection or XDatasetCollectionTS).
+// The style is PrintText, PrintJSON or PrintYAML; MaxDepth replaces the deeper levels by "..." and MaxLength truncates the long values.
+//
+//	fmt.Println(data.Pretty(&xcore.XDatasetPrintOptions{Style: xcore.PrintYAML, MaxDepth: 3, MaxLength: 80}))
+//
+// Hash (or the Hash method) builds a canonical SHA-256 of the full nested content: the keys are sorted, the numbers are compared by value (1 and 1.0 are the same) and the times in UTC.
+// It can be used as a key of a XCache or to detect changes:
+//
+//	cache.Set(data.Hash(), result)
+//
+// 19. Generic accessors:
+//
+// Since v2.27.0 the module needs Go 1.20 or later.
+// GetAs[T], GetOr[T] and MustGet[T] read the value of a key (or path) of any XDatasetDef as the type T, with the conversion rules of GetString, GetBool, GetInt, GetFloat, GetTime and the Get*Collection.
+// The other types (XDatasetDef, XDatasetCollectionDef, structures...) are a type assertion of the value.
+//
+//	port, ok := xcore.GetAs[int](config, "db>port")
+//	timeout := xcore.GetOr(config, "db>timeout", 30.0)
+//	host := xcore.MustGet[string](config, "db>host") // panics if the key does not exist
+//
+// XDatasetGetter reads many values and accumulates the errors (ErrDatasetKey, ErrDatasetConvert), to check them once:
+//
+//	g := xcore.NewXDatasetGetter(config)
+//	host := g.String("db>host")
+//	port := g.Int("db>port")
+//	if err := g.Err(); err != nil {
+//	  return err
+//	}
+//
+// 20. Configuration:
+//
+// XDatasetConfig builds a configuration XDataset from layered sources, merged in the order they are added: each source overrides the previous ones.
+// The INI files use the XLanguage key=value format, with [section] for the nested datasets; the environment variables use a prefix and "__" as the path separator;
+// only the flags set on the command line are used, with "." as the path separator.
+//
+//	config := xcore.NewXDatasetConfig()
+//	config.AddDataset("defaults", &xcore.XDataset{"db": &xcore.XDataset{"host": "localhost", "port": 5432}})
+//	err := config.AddINIFile("/etc/app/app.ini")
+//	err = config.AddJSONFile("/etc/app/local.json")
+//	err = config.AddEnv("APP_")  // APP_DB__HOST=remote sets db>host
+//	err = config.AddFlags(flag.CommandLine) // -db.port=7000 sets db>port
+//	data := config.Dataset()
+//	source := config.Source("db>host") // "env:APP_DB__HOST"
+//
 // # XDataSetTS
 //
 // 1. Overview:
@@ -313,6 +582,30 @@
 // Note that all references to XDatasetTS are pointers, always (to be able to modify the values of them).
 // The DatasetTS meet the XDatasetDef interface
 //
+// Since v2.23.0, the XDatasetTS and XDatasetCollectionTS are thread safe in depth: the nested XDataset and XDatasetCollection are encapsulated into
+// XDatasetTS and XDatasetCollectionTS by NewXDatasetTS, Set, SetPath, Push, Insert... so GetDataset and GetCollection return thread safe values.
+// NewXDatasetTS, Set, SetPath, Push, Insert... always encapsulate a copy of the XDataset and XDatasetCollection they are given:
+// the data of the caller is never modified nor shared, and the changes made through the thread safe structure are not visible into it.
+//
+//	datats := xcore.NewXDatasetTS(&xcore.XDataset{"config": &xcore.XDataset{"host": "localhost"}})
+//	config, _ := datats.GetDataset("config") // *xcore.XDatasetTS
+//	config.Set("port", 80) // safe from any goroutine
+//
+// Since v2.24.0, the read-modify-write sequences are atomic with the transactions of XDatasetTS and XDatasetCollectionTS:
+// Update calls a function under the write lock, View under the read lock, CompareAndSet sets a value only if the current one is the expected one,
+// and Increment adds a delta to an integer value.
+//
+//	datats.Increment("visits", 1)
+//	datats.CompareAndSet("status", "pending", "done")
+//	datats.Update(func(d xcore.XDatasetDef) error {
+//	  balance, _ := d.GetFloat("balance")
+//	  d.Set("balance", balance-10)
+//	  d.Set("lastpayment", 10)
+//	  return nil
+//	})
+//
+// The function of Update and View must use the dataset it receives, not the XDatasetTS itself.
+//
 // # XTemplate
 //
 // 1. Overview:
@@ -611,6 +904,23 @@
 //
 //	{{detail>data1>data2>key2>status}}
 //
+// 3.3.5 Level selectors: ..>id, />id and @N>id
+//
+// The scope search stops at the first level that contains the id, so an outer field with the same name as a local one cannot be reached by the scope.
+// You can start the path with a level selector to point directly a level of the data stack:
+//
+// - ..> is the parent level. It can be repeated to go up more levels: {{..>..>name}}
+//
+// - /> is the root level, the main dataset injected into the template: {{/>appname}}
+//
+// - @N> is the level N of the stack, 0-based (@0 is the root): {{@1>name}}
+//
+// The selectors work in fields, loops, conditions and references:
+//
+//	@@hobbies:hobby@@
+//	[[hobby]]{{name}} is a hobby of {{..>name}} ??/>vip:vip??[[]]
+//	[[vip]]VIP client[[]]
+//
 // 3.4 Meta Elements
 //
 // They consist into an injection of a XDataset, called the "data to inject", into the template.
@@ -736,8 +1046,16 @@
 //
 // - templateid in all other cases (odd is contained here if even is defined)
 //
+// If the templateid.separator template exists, it is inserted between the elements of the loop (for instance a comma).
+//
 // Since v2.1.7, you can also use the pseudo field {{.counter}} into the loop subtemplate, to get the number of the counter of the loop, it is 1-based (first loop is 1, not 0)
 //
+// Since v2.22.0, the pseudo fields are provided by the loop over the entry: they are not set into the datasets of the collection anymore.
+//
+// Since v2.20.0, when the collection is a XDatasetKeyedCollection, the templateid.key.[value] uses the key of the entry (then the index), and the pseudo field {{.key}} contains the key of the entry.
+//
+// Since v2.21.0, when the collection is a XDatasetLazyCollection, the loop reads the entries page by page without calling Count, and .last is known with a look-ahead on the next page.
+//
 // 3.4.2.2 When order is a single id (characters a-z0-9.-_), it will make a call to the sub template id with the same subset of data with the same id and replace the @@...@@ for each itterance of the data with the result.
 //
 // Example based on previous array of Fred's data:
@@ -781,6 +1099,11 @@
 //	[[hobby]]{{name}}<br />[[]]
 //	[[]]
 //
+// 3.4.2.4 When the data id is a query (see the Query of the XDataset), the loop iterates over the datasets selected by the query:
+//
+//	@@hobbies>[sport=yes]:hobby@@ %-- will iterate over the hobbies that are a sport --%
+//	@@orders>**>[total]:line@@     %-- will iterate over all the orders and lines of orders at any depth with a total --%
+//
 // 3.4.3 Conditional: ??order??
 //
 // Makes a call to a subtemplate only if the field exists and have a value.
@@ -853,10 +1176,68 @@
 // 3.5.1 !!list!!
 //
 // Will show only the tree of parameters, values are not shown.
+//
+// 3.6 Execution limits
+//
+// A template that calls itself (for instance &&a&& into [[a]]) would recurse forever. Execute is protected with the DefaultXTemplateLimits and returns the error message as the result when a limit is reached.
+//
+// Use ExecuteContext to control the execution with a context.Context and your own limits, for instance when the templates are editable by the users:
+//
+//	limits := &xcore.XTemplateLimits{
+//	  MaxDepth:  50,               // max nested calls of sub templates
+//	  MaxOutput: 1024 * 1024,      // max bytes of the result
+//	  MaxLoops:  10000,            // max iterations of all the loops
+//	  Timeout:   time.Second,      // max duration of the execution
+//	}
+//	result, err := tmpl.ExecuteContext(ctx, data, limits)
+//	if err != nil {
+//	  // xcore.ErrTemplateMaxDepth, xcore.ErrTemplateMaxOutput, xcore.ErrTemplateMaxLoops, context.Canceled or context.DeadlineExceeded
+//	}
+//
+// 3.7 HTML and XML minification
+//
+// A template declared as HTML or XML with its Format can be minified once, after its compilation: the <!-- --> comments are removed from the strings of the template and all its sub templates.
+// For XML, the white spaces between tags are removed. For HTML, the runs of white spaces are collapsed into one space (the spaces between inline elements are meaningful),
+// except into the preserved tags (pre, textarea and script by default).
+// The CDATA sections are never modified.
+//
+//	tmpl, _ := xcore.NewXTemplateFromFile("page.template")
+//	tmpl.Format = xcore.FormatHTML
+//	err := tmpl.Minify(&xcore.XTemplateMinify{Preserve: []string{"pre", "textarea", "script", "style"}})
+//
+// 3.8 Sets of templates by format
+//
+// The XTemplateSet keeps the variants of the same templates in different formats (html, json, xml, text...) to build the same XDataset in any of them.
+// The template files are named name.format.template (name.template is a text template).
+// When the requested format does not exist, the fallback formats are used (html, then text, by default, see SetFallback).
+//
+//	set := xcore.NewXTemplateSet()
+//	err := set.LoadDir("templates/") // page.html.template, page.json.template, page.xml.template...
+//
+//	// Select by format
+//	result, format, err := set.Execute("page", xcore.FormatJSON, data)
+//
+//	// Select by the Accept header of the client
+//	result, format, err := set.ExecuteAccept("page", r.Header.Get("Accept"), data)
+//	w.Header().Set("Content-Type", xcore.ContentType(format))
+//
+// 3.9 JSON templates
+//
+// When the Format of the template is FormatJSON, the fields {{...}} and the language entries ##...## are encoded into JSON according to their type:
+// the strings are quoted and escaped, the booleans are true/false, the times are RFC 3339 strings, the missing fields are null, etc.
+// So the fields must not be quoted into the template. Use the .separator template to separate the elements of the loops.
+// The JSON mode is opt-in: a XTemplateSet does not change the Format of its templates, so the *.json.template files are in JSON mode only if their Format is set.
+// ExecuteContext returns ErrTemplateInvalidJSON if the result is not a valid JSON code.
+//
+//	tmpl, _ := xcore.NewXTemplateFromString(`{"name": {{clientname}}, "hobbies": [@@hobbies:hobby@@]}
+//	[[hobby]]{"name": {{name}}}[[]]
+//	[[hobby.separator]],[[]]`)
+//	tmpl.Format = xcore.FormatJSON
+//	result, err := tmpl.ExecuteContext(ctx, data, nil)
 package xcore
 
 // VERSION is the used version nombre of the XCore library.
-const VERSION = "2.3.2"
+const VERSION = "2.28.0"
 
 // LOG is the flag to activate logging on the library.
 // if LOG is set to TRUE, LOG indicates to the XCore libraries to log a trace of functions called, with most important parameters.
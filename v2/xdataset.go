@@ -1,6 +1,8 @@
 package xcore
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"sort"
 	"strconv"
@@ -17,30 +19,118 @@ import (
 type XDataset map[string]interface{}
 
 // NewXDataset is used to build an XDataset from a standard map
+// The nested maps are converted to XDataset, the slices of maps to XDatasetCollection and the homogeneous slices of scalars to []string, []int, []float64 or []bool
 func NewXDataset(data map[string]interface{}) XDatasetDef {
 	// Scan data and encapsulate it into the XDataset
 	ds := &XDataset{}
 	for i, v := range data {
-		switch v.(type) {
-		case []interface{}:
-			dsc := &XDatasetCollection{}
-			for _, it := range v.([]interface{}) {
-				dscit, ok := it.(map[string]interface{})
-				if ok {
-					dscc := NewXDataset(dscit)
-					dsc.Push(dscc)
-				}
+		(*ds)[i] = newXDatasetValue(v)
+	}
+	return ds
+}
+
+// newXDatasetValue will convert a generic value (for instance decoded from JSON) to the XDataset types:
+// map[string]interface{} to *XDataset, slices of maps to *XDatasetCollection, homogeneous slices of scalars to []string, []int, []float64, []bool, json.Number to int or float64
+func newXDatasetValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		return NewXDataset(val)
+	case []map[string]interface{}:
+		return NewXDatasetCollection(val)
+	case json.Number:
+		if i, err := strconv.Atoi(string(val)); err == nil {
+			return i
+		}
+		f, _ := val.Float64()
+		return f
+	case []interface{}:
+		items := make([]interface{}, len(val))
+		for i, it := range val {
+			items[i] = newXDatasetValue(it)
+		}
+		return newXDatasetSlice(items)
+	}
+	return v
+}
+
+// newXDatasetSlice will convert a slice of already converted values to the most precise type of slice
+func newXDatasetSlice(items []interface{}) interface{} {
+	if len(items) == 0 {
+		return &XDatasetCollection{}
+	}
+	var datasets, strs, ints, floats, bools int
+	for _, it := range items {
+		switch it.(type) {
+		case XDatasetDef:
+			datasets++
+		case string:
+			strs++
+		case int:
+			ints++
+		case float64:
+			floats++
+		case bool:
+			bools++
+		}
+	}
+	switch len(items) {
+	case datasets:
+		dsc := &XDatasetCollection{}
+		for _, it := range items {
+			dsc.Push(it.(XDatasetDef))
+		}
+		return dsc
+	case strs:
+		result := make([]string, len(items))
+		for i, it := range items {
+			result[i] = it.(string)
+		}
+		return result
+	case ints:
+		result := make([]int, len(items))
+		for i, it := range items {
+			result[i] = it.(int)
+		}
+		return result
+	case ints + floats:
+		result := make([]float64, len(items))
+		for i, it := range items {
+			if v, ok := it.(int); ok {
+				result[i] = float64(v)
+			} else {
+				result[i] = it.(float64)
 			}
-			ds.Set(i, dsc)
-		case []map[string]interface{}:
-			ds.Set(i, NewXDatasetCollection(v.([]map[string]interface{})))
-		case map[string]interface{}:
-			ds.Set(i, NewXDataset(v.(map[string]interface{})))
-		default:
-			ds.Set(i, v)
 		}
+		return result
+	case bools:
+		result := make([]bool, len(items))
+		for i, it := range items {
+			result[i] = it.(bool)
+		}
+		return result
 	}
-	return ds
+	return items
+}
+
+// MarshalJSON will encode the XDataset and all its nested data into JSON
+func (d *XDataset) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]interface{}(*d))
+}
+
+// UnmarshalJSON will decode the JSON object into the XDataset, replacing its content.
+// The nested objects become XDataset, the arrays of objects XDatasetCollection and the homogeneous arrays of scalars []string, []int, []float64 or []bool
+func (d *XDataset) UnmarshalJSON(data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var temp map[string]interface{}
+	if err := decoder.Decode(&temp); err != nil {
+		return err
+	}
+	*d = XDataset{}
+	for i, v := range temp {
+		(*d)[i] = newXDatasetValue(v)
+	}
+	return nil
 }
 
 // String will transform the XDataset into a readable string for humans
@@ -63,9 +153,34 @@ func (d *XDataset) GoString() string {
 	return "#xcore.XDataset{" + strings.Join(sdata, " ") + "}"
 }
 
-// Set will add a variable key with value data to the XDataset
+// Set will add a variable key with value data to the XDataset.
+// The key may be a path "a>b>c" through the nested datasets and collections (the numeric entries are the indexes of the collections).
+// The missing intermediate entries are created as XDataset. If the path cannot be followed (an intermediate entry is not a dataset or a collection),
+// the data is stored under the literal key "a>b>c" as before the paths, so no write is lost (use SetPath to get the error instead)
 func (d *XDataset) Set(key string, data interface{}) {
-	(*d)[key] = data
+	if err := d.SetPath(key, data, false); err != nil {
+		(*d)[key] = data
+	}
+}
+
+// SetPath will set the data at the path key "a>b>c" of the XDataset, creating the missing intermediate entries.
+// The intermediate entries are created as XDataset, or as XDatasetCollection if collections is true and the next entry of the path is numeric.
+// The index just after the last entry of a collection adds a new entry to the collection.
+// Returns an error if an intermediate entry is not a dataset or a collection, or if an index is out of the collection
+func (d *XDataset) SetPath(key string, data interface{}, collections bool) error {
+	head, rest, sub := splitPath(key)
+	if !sub {
+		(*d)[key] = data
+		return nil
+	}
+	child, err := setPathChild((*d)[head], rest, data, collections)
+	if err != nil {
+		return err
+	}
+	if child != nil {
+		(*d)[head] = child
+	}
+	return nil
 }
 
 // Get will read the value of the key variable
@@ -80,12 +195,8 @@ func (d *XDataset) Get(key string) (interface{}, bool) {
 			return ds.Get(strings.Join(xid[1:], ">"))
 		}
 		if dsc, ok := subset.(XDatasetCollectionDef); ok {
-			entry, err := strconv.Atoi(xid[1])
-			if err != nil {
-				return nil, false
-			}
-			ds, _ := dsc.Get(entry)
-			if ds == nil {
+			ds, ok := collectionEntry(dsc, xid[1])
+			if !ok {
 				return nil, false
 			}
 			if len(xid) == 2 {
@@ -124,10 +235,7 @@ func (d *XDataset) GetCollection(key string) (XDatasetCollectionDef, bool) {
 // GetString will read the value of the key variable as a string cast type
 func (d *XDataset) GetString(key string) (string, bool) {
 	if val, ok := d.Get(key); ok {
-		if val == nil {
-			return "", true
-		}
-		return fmt.Sprint(val), true
+		return convertString(val)
 	}
 	return "", false
 }
@@ -137,53 +245,7 @@ func (d *XDataset) GetString(key string) (string, bool) {
 // If the value is anything else and it exists, it will return true if it's not nil
 func (d *XDataset) GetBool(key string) (bool, bool) {
 	if val, ok := d.Get(key); ok {
-		if val2, ok2 := val.(bool); ok2 {
-			return val2, true
-		}
-		if val2, ok2 := val.(time.Time); ok2 {
-			return !val2.Equal(time.Time{}), true
-		}
-		if val2, ok2 := val.(int); ok2 {
-			return val2 != 0, true
-		}
-		if val2, ok2 := val.(int8); ok2 {
-			return val2 != 0, true
-		}
-		if val2, ok2 := val.(int16); ok2 {
-			return val2 != 0, true
-		}
-		if val2, ok2 := val.(int32); ok2 {
-			return val2 != 0, true
-		}
-		if val2, ok2 := val.(int64); ok2 {
-			return val2 != 0, true
-		}
-		if val2, ok2 := val.(uint); ok2 {
-			return val2 != 0, true
-		}
-		if val2, ok2 := val.(uint8); ok2 {
-			return val2 != 0, true
-		}
-		if val2, ok2 := val.(uint16); ok2 {
-			return val2 != 0, true
-		}
-		if val2, ok2 := val.(uint32); ok2 {
-			return val2 != 0, true
-		}
-		if val2, ok2 := val.(uint64); ok2 {
-			return val2 != 0, true
-		}
-		// byte is alias of uint8, no need for it
-		//		if val2, ok2 := val.(byte); ok2 {
-		//			return val2 != 0, true
-		//		}
-		if val2, ok2 := val.(float32); ok2 {
-			return val2 != 0, true
-		}
-		if val2, ok2 := val.(float64); ok2 {
-			return val2 != 0, true
-		}
-		return val != nil, true
+		return convertBool(val)
 	}
 	return false, false
 }
@@ -193,57 +255,7 @@ func (d *XDataset) GetBool(key string) (bool, bool) {
 // If the value is float, will return integer part of value
 func (d *XDataset) GetInt(key string) (int, bool) {
 	if val, ok := d.Get(key); ok {
-		if val2, ok2 := val.(bool); ok2 {
-			if val2 {
-				return 1, true
-			}
-			return 0, true
-		}
-		if val2, ok2 := val.(int); ok2 {
-			return val2, true
-		}
-		if val2, ok2 := val.(int8); ok2 {
-			return int(val2), true
-		}
-		if val2, ok2 := val.(int16); ok2 {
-			return int(val2), true
-		}
-		if val2, ok2 := val.(int32); ok2 {
-			return int(val2), true
-		}
-		if val2, ok2 := val.(int64); ok2 {
-			return int(val2), true
-		}
-		if val2, ok2 := val.(uint); ok2 {
-			return int(val2), true
-		}
-		if val2, ok2 := val.(uint8); ok2 {
-			return int(val2), true
-		}
-		if val2, ok2 := val.(uint16); ok2 {
-			return int(val2), true
-		}
-		if val2, ok2 := val.(uint32); ok2 {
-			return int(val2), true
-		}
-		if val2, ok2 := val.(uint64); ok2 {
-			return int(val2), true
-		}
-		//		if val2, ok2 := val.(byte); ok2 {
-		//			return int(val2), true
-		//		}
-		if val2, ok2 := val.(float32); ok2 {
-			return int(val2), true
-		}
-		if val2, ok2 := val.(float64); ok2 {
-			return int(val2), true
-		}
-		if val2, ok2 := val.(time.Time); ok2 {
-			if val2.Equal(time.Time{}) {
-				return 0, true
-			}
-			return int(val2.Unix()), true
-		}
+		return convertInt(val)
 	}
 	return 0, false
 }
@@ -251,132 +263,127 @@ func (d *XDataset) GetInt(key string) (int, bool) {
 // GetFloat will read the value of the key variable as a float64 cast type
 func (d *XDataset) GetFloat(key string) (float64, bool) {
 	if val, ok := d.Get(key); ok {
-		if val2, ok2 := val.(bool); ok2 {
-			if val2 {
-				return 1.0, true
-			}
-			return 0.0, true
-		}
-		if val2, ok2 := val.(int); ok2 {
-			return float64(val2), true
-		}
-		if val2, ok2 := val.(int8); ok2 {
-			return float64(val2), true
-		}
-		if val2, ok2 := val.(int16); ok2 {
-			return float64(val2), true
-		}
-		if val2, ok2 := val.(int32); ok2 {
-			return float64(val2), true
-		}
-		if val2, ok2 := val.(int64); ok2 {
-			return float64(val2), true
-		}
-		if val2, ok2 := val.(uint); ok2 {
-			return float64(val2), true
-		}
-		if val2, ok2 := val.(uint8); ok2 {
-			return float64(val2), true
-		}
-		if val2, ok2 := val.(uint16); ok2 {
-			return float64(val2), true
-		}
-		if val2, ok2 := val.(uint32); ok2 {
-			return float64(val2), true
-		}
-		if val2, ok2 := val.(uint64); ok2 {
-			return float64(val2), true
-		}
-		//		if val2, ok2 := val.(byte); ok2 {
-		//			return float64(val2), true
-		//		}
-		if val2, ok2 := val.(float32); ok2 {
-			return float64(val2), true
-		}
-		if val2, ok2 := val.(float64); ok2 {
-			return val2, true
-		}
-		if val2, ok2 := val.(time.Time); ok2 {
-			if val2.Equal(time.Time{}) {
-				return 0, true
-			}
-			return float64(val2.Unix()), true
-		}
+		return convertFloat(val)
 	}
 	return 0.0, false
 }
 
 // GetTime will read the value of the key variable as a time cast type
+// If the value is a string, it will be parsed with the XDatasetTimeLayouts
+// If the value is a number, it is the unix time in seconds (0 is the zero time)
 func (d *XDataset) GetTime(key string) (time.Time, bool) {
 	if val, ok := d.Get(key); ok {
-		if val2, ok2 := val.(time.Time); ok2 {
-			return val2, true
-		}
-		// int, float, conversion ?
+		return convertTime(val)
 	}
 	return time.Time{}, false
 }
 
 // GetStringCollection will read the value of the key variable as a collection of strings cast type
+// If the value is a slice of another type ([]interface{} for instance), each element will be converted with the rules of GetString
 func (d *XDataset) GetStringCollection(key string) ([]string, bool) {
 	if val, ok := d.Get(key); ok {
-		if val2, ok2 := val.([]string); ok2 {
-			return val2, true
-		}
-		// other types: conversion?
+		return convertStringCollection(val)
 	}
 	return nil, false
 }
 
 // GetBoolCollection will read the value of the key variable as a collection of bool cast type
+// If the value is a slice of another type ([]interface{} for instance), each element will be converted with the rules of GetBool
 func (d *XDataset) GetBoolCollection(key string) ([]bool, bool) {
 	if val, ok := d.Get(key); ok {
-		if val2, ok2 := val.([]bool); ok2 {
-			return val2, true
-		}
-		// other types: conversion?
+		return convertBoolCollection(val)
 	}
 	return nil, false
 }
 
 // GetIntCollection will read the value of the key variable as a collection of int cast type
+// If the value is a slice of another type ([]interface{} for instance), each element will be converted with the rules of GetInt
 func (d *XDataset) GetIntCollection(key string) ([]int, bool) {
 	if val, ok := d.Get(key); ok {
-		if val2, ok2 := val.([]int); ok2 {
-			return val2, true
-		}
-		// other types: conversion?
+		return convertIntCollection(val)
 	}
 	return nil, false
 }
 
 // GetFloatCollection will read the value of the key variable as a collection of float cast type
-// If the field is not an array it will be converted to an array.
-// If the field is an array of another type, it will be converted.
+// If the value is a slice of another type ([]interface{} for instance), each element will be converted with the rules of GetFloat
 func (d *XDataset) GetFloatCollection(key string) ([]float64, bool) {
 	if val, ok := d.Get(key); ok {
-		if val2, ok2 := val.([]float64); ok2 {
-			return val2, true
-		}
-		// other types: conversion?
-
+		return convertFloatCollection(val)
 	}
 	return nil, false
 }
 
 // GetTimeCollection will read the value of the key variable as a collection of time cast type
+// If the value is a slice of another type ([]interface{} for instance), each element will be converted with the rules of GetTime
 func (d *XDataset) GetTimeCollection(key string) ([]time.Time, bool) {
 	if val, ok := d.Get(key); ok {
-		if val2, ok2 := val.([]time.Time); ok2 {
-			return val2, true
-		}
+		return convertTimeCollection(val)
 	}
 	return nil, false
 }
 
-// Del will deletes the variable
+// Del will deletes the variable. The key may be a path "a>b>c" through the nested datasets and collections.
+// If the last entry of the path is an index of a collection, the entry is removed from the collection
 func (d *XDataset) Del(key string) {
-	delete(*d, key)
+	head, rest, sub := splitPath(key)
+	if !sub {
+		delete(*d, key)
+		return
+	}
+	delPathChild((*d)[head], rest)
+}
+
+// Exists will check if the key exists into the XDataset. The key may be a path "a>b>c" through the nested datasets and collections
+func (d *XDataset) Exists(key string) bool {
+	_, ok := d.Get(key)
+	return ok
+}
+
+// Keys will return the sorted list of the keys of the XDataset
+func (d *XDataset) Keys() []string {
+	keys := make([]string, 0, len(*d))
+	for key := range *d {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// datasetKeys will return the sorted keys of a XDatasetDef if it is able to list them (it implements Keys() []string)
+func datasetKeys(ds XDatasetDef) ([]string, bool) {
+	if keyer, ok := ds.(interface{ Keys() []string }); ok {
+		return keyer.Keys(), true
+	}
+	return nil, false
+}
+
+// datasetKey will read the value of the literal key of the dataset, without following a path "a>b>c" (for the keys listed by datasetKeys)
+func datasetKey(ds XDatasetDef, key string) (interface{}, bool) {
+	switch d := ds.(type) {
+	case *XDataset:
+		value, ok := (*d)[key]
+		return value, ok
+	case interface {
+		getKey(string) (interface{}, bool)
+	}:
+		return d.getKey(key)
+	}
+	return ds.Get(key)
+}
+
+// setDatasetKey will set the data at the literal key of the dataset, without following a path "a>b>c" (for the copies of existing datasets)
+func setDatasetKey(ds XDatasetDef, key string, data interface{}) {
+	switch d := ds.(type) {
+	case *XDataset:
+		(*d)[key] = data
+	case interface {
+		setKey(string, interface{})
+	}:
+		d.setKey(key, data)
+	default:
+		ds.Set(key, data)
+	}
 }
 
 // Clone will creates a totally new data memory cloned from this object
@@ -391,7 +398,7 @@ func (d *XDataset) Clone() XDatasetDef {
 		if cloneable2, ok := val.(interface{ Clone() XDatasetCollectionDef }); ok {
 			clonedval = cloneable2.Clone()
 		}
-		cloned.Set(id, clonedval)
+		(*cloned)[id] = clonedval
 	}
 	return cloned
 }
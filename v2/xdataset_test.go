@@ -550,8 +550,8 @@ func TestXDataset_GetTime(t *testing.T) {
 			return
 		}
 	}
-	r, ok := data.GetTime("float641")
-	if !r.Equal(time.Time{}) || ok {
+	r, ok := data.GetTime("float641") // unix time
+	if !r.Equal(time.Unix(1, 0)) || !ok {
 		t.Error("Error getting time " + fmt.Sprintf("xxx %#v %#v", r, ok))
 		return
 	}
@@ -1,8 +1,12 @@
 package xcore
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -13,6 +17,9 @@ import (
 // XDatasetCollection is the basic collection of XDatasetDefs
 type XDatasetCollection []XDatasetDef
 
+// ErrCollectionIndex is the error returned when an index is out of the collection
+var ErrCollectionIndex = errors.New("Error: index out of range of the collection")
+
 // NewXDatasetCollection is used to build an XDatasetCollection from a standard []map
 func NewXDatasetCollection(data []map[string]interface{}) XDatasetCollectionDef {
 	// Scan data and encapsulate it into the XDataset
@@ -23,6 +30,29 @@ func NewXDatasetCollection(data []map[string]interface{}) XDatasetCollectionDef
 	return dsc
 }
 
+// MarshalJSON will encode the XDatasetCollection and all its nested data into a JSON array
+func (d *XDatasetCollection) MarshalJSON() ([]byte, error) {
+	if *d == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal([]XDatasetDef(*d))
+}
+
+// UnmarshalJSON will decode the JSON array of objects into the XDatasetCollection, replacing its content
+func (d *XDatasetCollection) UnmarshalJSON(data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var temp []map[string]interface{}
+	if err := decoder.Decode(&temp); err != nil {
+		return err
+	}
+	*d = XDatasetCollection{}
+	for _, v := range temp {
+		d.Push(NewXDataset(v))
+	}
+	return nil
+}
+
 // String will transform the XDataset into a readable string
 func (d *XDatasetCollection) String() string {
 	str := "XDatasetCollection["
@@ -43,11 +73,20 @@ func (d *XDatasetCollection) Unshift(data XDatasetDef) {
 	*d = append([]XDatasetDef{data}, (*d)...)
 }
 
-// Shift will remove the element at the beginning of the collection
+// Shift will remove the element at the beginning of the collection. Returns nil if the collection is empty
 func (d *XDatasetCollection) Shift() XDatasetDef {
+	data, _ := d.TryShift()
+	return data
+}
+
+// TryShift will remove the element at the beginning of the collection, or return false if the collection is empty
+func (d *XDatasetCollection) TryShift() (XDatasetDef, bool) {
+	if len(*d) == 0 {
+		return nil, false
+	}
 	data := (*d)[0]
 	*d = (*d)[1:]
-	return data
+	return data, true
 }
 
 // Push will adds a XDatasetDef at the end of the collection
@@ -55,11 +94,51 @@ func (d *XDatasetCollection) Push(data XDatasetDef) {
 	*d = append(*d, data)
 }
 
-// Pop will remove the element at the end of the collection
+// Pop will remove the element at the end of the collection. Returns nil if the collection is empty
 func (d *XDatasetCollection) Pop() XDatasetDef {
+	data, _ := d.TryPop()
+	return data
+}
+
+// TryPop will remove the element at the end of the collection, or return false if the collection is empty
+func (d *XDatasetCollection) TryPop() (XDatasetDef, bool) {
+	if len(*d) == 0 {
+		return nil, false
+	}
 	data := (*d)[len(*d)-1]
 	*d = (*d)[:len(*d)-1]
-	return data
+	return data, true
+}
+
+// Insert will insert the element at the index of the collection (0 to Count), moving the next elements
+func (d *XDatasetCollection) Insert(index int, data XDatasetDef) error {
+	return sliceInsert((*[]XDatasetDef)(d), index, data)
+}
+
+// RemoveAt will remove the element at the index of the collection and return it
+func (d *XDatasetCollection) RemoveAt(index int) (XDatasetDef, error) {
+	return sliceRemoveAt((*[]XDatasetDef)(d), index)
+}
+
+// Set will replace the element at the index of the collection
+func (d *XDatasetCollection) Set(index int, data XDatasetDef) error {
+	if index < 0 || index >= len(*d) {
+		return ErrCollectionIndex
+	}
+	(*d)[index] = data
+	return nil
+}
+
+// Slice will build a new collection with the elements from start to end (excluded) of the collection. The indexes are limited to the size of the collection.
+// The elements are not cloned
+func (d *XDatasetCollection) Slice(start int, end int) XDatasetCollectionDef {
+	result := XDatasetCollection(sliceSlice(*d, start, end))
+	return &result
+}
+
+// Clear will remove all the elements of the collection
+func (d *XDatasetCollection) Clear() {
+	*d = XDatasetCollection{}
 }
 
 // Count will return the quantity of elements into the collection
@@ -75,8 +154,61 @@ func (d *XDatasetCollection) Get(index int) (XDatasetDef, bool) {
 	return (*d)[index], true
 }
 
+// SetPath will set the data at the path "index>a>b" of the collection, creating the missing intermediate entries (see XDataset.SetPath).
+// If the path is only an index, the data must be a XDatasetDef that replaces the entry. The index just after the last entry adds a new entry to the collection
+func (d *XDatasetCollection) SetPath(path string, data interface{}, collections bool) error {
+	return sliceSetPath((*[]XDatasetDef)(d), path, data, collections)
+}
+
+// Del will delete the data at the path "index>a>b" of the collection. If the path is only an index, the entry is removed from the collection
+func (d *XDatasetCollection) Del(path string) {
+	sliceDel((*[]XDatasetDef)(d), path)
+}
+
+// Exists will check if there is a data at the path "index>a>b" of the collection
+func (d *XDatasetCollection) Exists(path string) bool {
+	return sliceExists(*d, path)
+}
+
+// scopeSelector will interpret the level selectors at the beginning of the path key into a collection of count entries:
+// "/>" is the root level (index 0), "@N>" is the level N (0-based), and every "..>" goes up one level from the last entry (or from the selected level).
+// Returns the index of the selected level, the rest of the path and true if the path starts with a selector.
+func scopeSelector(key string, count int) (int, string, bool) {
+	xid := strings.Split(key, ">")
+	level := count - 1
+	selected := false
+	i := 0
+	for ; i < len(xid); i++ {
+		if xid[i] == ".." {
+			level--
+		} else if i == 0 && xid[i] == "/" {
+			level = 0
+		} else if i == 0 && len(xid[i]) > 1 && xid[i][0] == '@' {
+			n, err := strconv.Atoi(xid[i][1:])
+			if err != nil {
+				break
+			}
+			level = n
+		} else {
+			break
+		}
+		selected = true
+	}
+	return level, strings.Join(xid[i:], ">"), selected
+}
+
 // GetData will retrieve the first available data identified by key from the collection ordered by index
+// The key may start with level selectors: "..>" for the parent level, "/>" for the root level and "@N>" for the level N of the collection.
 func (d *XDatasetCollection) GetData(key string) (interface{}, bool) {
+	if level, subkey, ok := scopeSelector(key, len(*d)); ok {
+		if level < 0 || level >= len(*d) {
+			return nil, false
+		}
+		if subkey == "" {
+			return (*d)[level], true
+		}
+		return (*d)[level].Get(subkey)
+	}
 	for i := len(*d) - 1; i >= 0; i-- {
 		val, ok := (*d)[i].Get(key)
 		if ok {
@@ -89,10 +221,7 @@ func (d *XDatasetCollection) GetData(key string) (interface{}, bool) {
 // GetDataString will retrieve the first available data identified by key from the collection ordered by index and return it as a string
 func (d *XDatasetCollection) GetDataString(key string) (string, bool) {
 	if val, ok := d.GetData(key); ok {
-		if val == nil {
-			return "", true
-		}
-		return fmt.Sprint(val), true
+		return convertString(val)
 	}
 	return "", false
 }
@@ -100,9 +229,7 @@ func (d *XDatasetCollection) GetDataString(key string) (string, bool) {
 // GetDataBool will retrieve the first available data identified by key from the collection ordered by index and return it as a boolean
 func (d *XDatasetCollection) GetDataBool(key string) (bool, bool) {
 	if val, ok := d.GetData(key); ok {
-		if val2, ok2 := val.(bool); ok2 {
-			return val2, true
-		}
+		return convertBool(val)
 	}
 	return false, false
 }
@@ -110,9 +237,7 @@ func (d *XDatasetCollection) GetDataBool(key string) (bool, bool) {
 // GetDataInt will retrieve the first available data identified by key from the collection ordered by index and return it as an integer
 func (d *XDatasetCollection) GetDataInt(key string) (int, bool) {
 	if val, ok := d.GetData(key); ok {
-		if val2, ok2 := val.(int); ok2 {
-			return val2, true
-		}
+		return convertInt(val)
 	}
 	return 0, false
 }
@@ -120,9 +245,7 @@ func (d *XDatasetCollection) GetDataInt(key string) (int, bool) {
 // GetDataFloat will retrieve the first available data identified by key from the collection ordered by index and return it as a float
 func (d *XDatasetCollection) GetDataFloat(key string) (float64, bool) {
 	if val, ok := d.GetData(key); ok {
-		if val2, ok2 := val.(float64); ok2 {
-			return val2, true
-		}
+		return convertFloat(val)
 	}
 	return 0, false
 }
@@ -130,9 +253,7 @@ func (d *XDatasetCollection) GetDataFloat(key string) (float64, bool) {
 // GetDataTime will retrieve the first available data identified by key from the collection ordered by index and return it as a time
 func (d *XDatasetCollection) GetDataTime(key string) (time.Time, bool) {
 	if val, ok := d.GetData(key); ok {
-		if val2, ok2 := val.(time.Time); ok2 {
-			return val2, true
-		}
+		return convertTime(val)
 	}
 	return time.Time{}, false
 }
@@ -158,3 +279,38 @@ func (d *XDatasetCollection) Clone() XDatasetCollectionDef {
 	}
 	return cloned
 }
+
+// sliceInsert will insert the dataset at the index of the slice (0 to len)
+func sliceInsert(data *[]XDatasetDef, index int, ds XDatasetDef) error {
+	if index < 0 || index > len(*data) {
+		return ErrCollectionIndex
+	}
+	*data = append(*data, nil)
+	copy((*data)[index+1:], (*data)[index:])
+	(*data)[index] = ds
+	return nil
+}
+
+// sliceRemoveAt will remove the dataset at the index of the slice and return it
+func sliceRemoveAt(data *[]XDatasetDef, index int) (XDatasetDef, error) {
+	if index < 0 || index >= len(*data) {
+		return nil, ErrCollectionIndex
+	}
+	ds := (*data)[index]
+	*data = append((*data)[:index], (*data)[index+1:]...)
+	return ds, nil
+}
+
+// sliceSlice will copy the datasets from start to end (excluded), limited to the size of the slice
+func sliceSlice(data []XDatasetDef, start int, end int) []XDatasetDef {
+	if start < 0 {
+		start = 0
+	}
+	if end > len(data) {
+		end = len(data)
+	}
+	if start >= end {
+		return []XDatasetDef{}
+	}
+	return append([]XDatasetDef{}, data[start:end]...)
+}
@@ -47,3 +47,39 @@ func ExampleXDatasetCollection() {
 func TestLoadJSONInXDatasetCollectionTS(t *testing.T) {
 
 }
+
+func TestXDatasetCollection_GetDataSelectors(t *testing.T) {
+
+	stacks := []XDatasetCollectionDef{&XDatasetCollection{}, &XDatasetCollectionTS{}}
+	for _, stack := range stacks {
+		stack.Push(&XDataset{"name": "root", "appname": "XCore"})
+		stack.Push(&XDataset{"name": "level1", "sub": &XDataset{"name": "sub1"}})
+		stack.Push(&XDataset{"name": "level2"})
+
+		tests := map[string]interface{}{
+			"name":          "level2",
+			"appname":       "XCore",
+			"..>name":       "level1",
+			"..>..>name":    "root",
+			"..>sub>name":   "sub1",
+			"/>name":        "root",
+			"@0>name":       "root",
+			"@1>name":       "level1",
+			"@2>name":       "level2",
+			"@1>..>name":    "root",
+			"..>..>..>name": nil,
+			"@5>name":       nil,
+			"/>sub":         nil,
+			"@x>name":       nil,
+		}
+		for key, expected := range tests {
+			val, _ := stack.GetData(key)
+			if val != expected {
+				t.Errorf("Error getting %s from %T: %v", key, stack, val)
+			}
+		}
+		if val, ok := stack.GetData(".."); !ok || val.(XDatasetDef) == nil {
+			t.Errorf("Error getting the parent dataset from %T", stack)
+		}
+	}
+}
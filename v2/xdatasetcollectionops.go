@@ -0,0 +1,367 @@
+package xcore
+
+import (
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// compareField will compare the values of the field of two datasets: -1 if a < b, 0 if equal, 1 if a > b.
+// The values are ordered by type first: missing values, booleans (false < true), numbers, times, strings, then any other value.
+// The numbers (the int, uint and float types, not the numeric strings) are compared as numbers, NaN before all the other numbers,
+// the times as times, the strings as strings and the other values as their strings (GetString), so the order is total.
+func compareField(a XDatasetDef, b XDatasetDef, field string) int {
+	va, oka := a.Get(field)
+	vb, okb := b.Get(field)
+	return compareValues(va, oka && va != nil, vb, okb && vb != nil)
+}
+
+// The ranks of the types of values of compareValues, in order
+const (
+	compareMissing = iota
+	compareBool
+	compareNumber
+	compareTime
+	compareString
+	compareOther
+)
+
+// compareRank will return the rank of the type of the value, for compareValues
+func compareRank(value interface{}, ok bool) int {
+	if !ok {
+		return compareMissing
+	}
+	switch value.(type) {
+	case bool:
+		return compareBool
+	case time.Time:
+		return compareTime
+	case string:
+		return compareString
+	}
+	if _, isnumber := numberValue(value); isnumber {
+		return compareNumber
+	}
+	return compareOther
+}
+
+// compareValues will compare two values with the rules of compareField
+func compareValues(va interface{}, oka bool, vb interface{}, okb bool) int {
+	ra, rb := compareRank(va, oka), compareRank(vb, okb)
+	switch {
+	case ra < rb:
+		return -1
+	case ra > rb:
+		return 1
+	}
+	switch ra {
+	case compareMissing:
+		return 0
+	case compareBool:
+		ba, bb := va.(bool), vb.(bool)
+		switch {
+		case ba == bb:
+			return 0
+		case bb:
+			return -1
+		}
+		return 1
+	case compareNumber:
+		fa, _ := numberValue(va)
+		fb, _ := numberValue(vb)
+		nana, nanb := math.IsNaN(fa), math.IsNaN(fb)
+		switch {
+		case nana && nanb:
+			return 0
+		case nana || fa < fb:
+			return -1
+		case nanb || fa > fb:
+			return 1
+		}
+		return 0
+	case compareTime:
+		ta, tb := va.(time.Time), vb.(time.Time)
+		switch {
+		case ta.Before(tb):
+			return -1
+		case ta.After(tb):
+			return 1
+		}
+		return 0
+	}
+	sa, _ := convertString(va)
+	sb, _ := convertString(vb)
+	return strings.Compare(sa, sb)
+}
+
+// sliceSort will sort the slice of datasets by the fields, in order. A field starting with "-" sorts in descending order.
+// The sort is stable: the entries with the same values keep their order
+func sliceSort(data []XDatasetDef, fields []string) {
+	sort.SliceStable(data, func(i, j int) bool {
+		for _, field := range fields {
+			desc := strings.HasPrefix(field, "-")
+			c := compareField(data[i], data[j], strings.TrimPrefix(field, "-"))
+			if c == 0 {
+				continue
+			}
+			if desc {
+				return c > 0
+			}
+			return c < 0
+		}
+		return false
+	})
+}
+
+// sliceFilter will return the datasets of the slice for which the predicate is true
+func sliceFilter(data []XDatasetDef, predicate func(XDatasetDef) bool) []XDatasetDef {
+	result := []XDatasetDef{}
+	for _, ds := range data {
+		if predicate(ds) {
+			result = append(result, ds)
+		}
+	}
+	return result
+}
+
+// sliceMap will return the datasets built by the function for each dataset of the slice. The nil results are ignored
+func sliceMap(data []XDatasetDef, mapper func(XDatasetDef) XDatasetDef) []XDatasetDef {
+	result := []XDatasetDef{}
+	for _, ds := range data {
+		if mapped := mapper(ds); mapped != nil {
+			result = append(result, mapped)
+		}
+	}
+	return result
+}
+
+// sliceIndexOf will return the index of the first dataset of the slice for which the predicate is true, or -1
+func sliceIndexOf(data []XDatasetDef, predicate func(XDatasetDef) bool) int {
+	for i, ds := range data {
+		if predicate(ds) {
+			return i
+		}
+	}
+	return -1
+}
+
+// sliceGroupBy will group the datasets of the slice by the value of the field (as a string). The datasets without the field are ignored
+func sliceGroupBy(data []XDatasetDef, field string) (map[string][]XDatasetDef, []string) {
+	groups := map[string][]XDatasetDef{}
+	keys := []string{}
+	for _, ds := range data {
+		key, ok := ds.GetString(field)
+		if !ok {
+			continue
+		}
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], ds)
+	}
+	return groups, keys
+}
+
+// sliceSum will return the sum of the values of the field converted to float (GetFloat), and the quantity of summed values
+func sliceSum(data []XDatasetDef, field string) (float64, int) {
+	sum := 0.0
+	count := 0
+	for _, ds := range data {
+		if f, ok := ds.GetFloat(field); ok {
+			sum += f
+			count++
+		}
+	}
+	return sum, count
+}
+
+// sliceExtreme will return the min (sign = -1) or max (sign = 1) value of the field, compared with the rules of compareField
+func sliceExtreme(data []XDatasetDef, field string, sign int) (interface{}, bool) {
+	var extreme interface{}
+	found := false
+	for _, ds := range data {
+		value, ok := ds.Get(field)
+		if !ok || value == nil {
+			continue
+		}
+		if !found || compareValues(value, true, extreme, true)*sign > 0 {
+			extreme = value
+			found = true
+		}
+	}
+	return extreme, found
+}
+
+// sliceDistinct will return the distinct values of the field, in order of first appearance
+func sliceDistinct(data []XDatasetDef, field string) []interface{} {
+	result := []interface{}{}
+	for _, ds := range data {
+		value, ok := ds.Get(field)
+		if !ok {
+			continue
+		}
+		exists := false
+		for _, v := range result {
+			if reflect.DeepEqual(v, value) {
+				exists = true
+				break
+			}
+		}
+		if !exists {
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
+// Sort will sort the entries of the collection by the fields, in order. A field starting with "-" sorts in descending order: Sort("country", "-age").
+// The times are compared as times, the numbers (GetFloat) as numbers and the other values as strings (GetString). The missing values are first.
+func (d *XDatasetCollection) Sort(fields ...string) {
+	sliceSort(*d, fields)
+}
+
+// Filter will build a new collection with the entries for which the predicate is true
+func (d *XDatasetCollection) Filter(predicate func(XDatasetDef) bool) XDatasetCollectionDef {
+	result := XDatasetCollection(sliceFilter(*d, predicate))
+	return &result
+}
+
+// Map will build a new collection with the datasets returned by the function for each entry. The nil results are ignored
+func (d *XDatasetCollection) Map(mapper func(XDatasetDef) XDatasetDef) XDatasetCollectionDef {
+	result := XDatasetCollection(sliceMap(*d, mapper))
+	return &result
+}
+
+// Find will return the first entry of the collection for which the predicate is true, or false if there is none
+func (d *XDatasetCollection) Find(predicate func(XDatasetDef) bool) (XDatasetDef, bool) {
+	if i := sliceIndexOf(*d, predicate); i >= 0 {
+		return (*d)[i], true
+	}
+	return nil, false
+}
+
+// IndexOf will return the index of the first entry of the collection for which the predicate is true, or -1 if there is none
+func (d *XDatasetCollection) IndexOf(predicate func(XDatasetDef) bool) int {
+	return sliceIndexOf(*d, predicate)
+}
+
+// GroupBy will build a XDataset with a collection of entries for each value (as a string) of the field. The entries without the field are ignored
+func (d *XDatasetCollection) GroupBy(field string) XDatasetDef {
+	groups, keys := sliceGroupBy(*d, field)
+	result := &XDataset{}
+	for _, key := range keys {
+		group := XDatasetCollection(groups[key])
+		(*result)[key] = &group
+	}
+	return result
+}
+
+// Sum will return the sum of the values of the field converted to float (GetFloat)
+func (d *XDatasetCollection) Sum(field string) float64 {
+	sum, _ := sliceSum(*d, field)
+	return sum
+}
+
+// Avg will return the average of the values of the field converted to float (GetFloat), or false if there is no value
+func (d *XDatasetCollection) Avg(field string) (float64, bool) {
+	sum, count := sliceSum(*d, field)
+	if count == 0 {
+		return 0, false
+	}
+	return sum / float64(count), true
+}
+
+// Min will return the minimum value of the field (compared as in Sort), or false if there is no value
+func (d *XDatasetCollection) Min(field string) (interface{}, bool) {
+	return sliceExtreme(*d, field, -1)
+}
+
+// Max will return the maximum value of the field (compared as in Sort), or false if there is no value
+func (d *XDatasetCollection) Max(field string) (interface{}, bool) {
+	return sliceExtreme(*d, field, 1)
+}
+
+// Distinct will return the distinct values of the field, in order of first appearance
+func (d *XDatasetCollection) Distinct(field string) []interface{} {
+	return sliceDistinct(*d, field)
+}
+
+// snapshot will copy the list of entries of the collection, so the functions of the caller are not called under the lock
+func (dc *XDatasetCollectionTS) snapshot() []XDatasetDef {
+	dc.mutex.RLock()
+	defer dc.mutex.RUnlock()
+	return append([]XDatasetDef{}, dc.data...)
+}
+
+// Sort will sort the entries of the collection by the fields (see XDatasetCollection.Sort)
+func (dc *XDatasetCollectionTS) Sort(fields ...string) {
+	dc.mutex.Lock()
+	defer dc.mutex.Unlock()
+	sliceSort(dc.data, fields)
+}
+
+// Filter will build a new XDatasetCollectionTS with the entries for which the predicate is true
+func (dc *XDatasetCollectionTS) Filter(predicate func(XDatasetDef) bool) XDatasetCollectionDef {
+	return &XDatasetCollectionTS{data: sliceFilter(dc.snapshot(), predicate)}
+}
+
+// Map will build a new XDatasetCollectionTS with the datasets returned by the function for each entry. The nil results are ignored
+func (dc *XDatasetCollectionTS) Map(mapper func(XDatasetDef) XDatasetDef) XDatasetCollectionDef {
+	return &XDatasetCollectionTS{data: sliceMap(dc.snapshot(), mapper)}
+}
+
+// Find will return the first entry of the collection for which the predicate is true, or false if there is none
+func (dc *XDatasetCollectionTS) Find(predicate func(XDatasetDef) bool) (XDatasetDef, bool) {
+	data := dc.snapshot()
+	if i := sliceIndexOf(data, predicate); i >= 0 {
+		return data[i], true
+	}
+	return nil, false
+}
+
+// IndexOf will return the index of the first entry of the collection for which the predicate is true, or -1 if there is none
+func (dc *XDatasetCollectionTS) IndexOf(predicate func(XDatasetDef) bool) int {
+	return sliceIndexOf(dc.snapshot(), predicate)
+}
+
+// GroupBy will build a XDatasetTS with a XDatasetCollectionTS of entries for each value (as a string) of the field. The entries without the field are ignored
+func (dc *XDatasetCollectionTS) GroupBy(field string) XDatasetDef {
+	groups, keys := sliceGroupBy(dc.snapshot(), field)
+	result := &XDataset{}
+	for _, key := range keys {
+		(*result)[key] = &XDatasetCollectionTS{data: groups[key]}
+	}
+	return newGuardedTS(result)
+}
+
+// Sum will return the sum of the values of the field converted to float (GetFloat)
+func (dc *XDatasetCollectionTS) Sum(field string) float64 {
+	sum, _ := sliceSum(dc.snapshot(), field)
+	return sum
+}
+
+// Avg will return the average of the values of the field converted to float (GetFloat), or false if there is no value
+func (dc *XDatasetCollectionTS) Avg(field string) (float64, bool) {
+	sum, count := sliceSum(dc.snapshot(), field)
+	if count == 0 {
+		return 0, false
+	}
+	return sum / float64(count), true
+}
+
+// Min will return the minimum value of the field (compared as in Sort), or false if there is no value
+func (dc *XDatasetCollectionTS) Min(field string) (interface{}, bool) {
+	return sliceExtreme(dc.snapshot(), field, -1)
+}
+
+// Max will return the maximum value of the field (compared as in Sort), or false if there is no value
+func (dc *XDatasetCollectionTS) Max(field string) (interface{}, bool) {
+	return sliceExtreme(dc.snapshot(), field, 1)
+}
+
+// Distinct will return the distinct values of the field, in order of first appearance
+func (dc *XDatasetCollectionTS) Distinct(field string) []interface{} {
+	return sliceDistinct(dc.snapshot(), field)
+}
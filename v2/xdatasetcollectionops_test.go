@@ -0,0 +1,152 @@
+package xcore
+
+import (
+	"fmt"
+	"math"
+	"testing"
+	"time"
+)
+
+func getOpsCollection() []XDatasetDef {
+	t1, _ := time.Parse("2006-01-02", "2020-01-01")
+	t2, _ := time.Parse("2006-01-02", "2019-06-01")
+	return []XDatasetDef{
+		&XDataset{"name": "Fred", "country": "MX", "age": 30, "salary": 3500.5, "hired": t1},
+		&XDataset{"name": "Juan", "country": "MX", "age": "9", "salary": 1200, "hired": t2},
+		&XDataset{"name": "Ana", "country": "FR", "age": 45, "salary": 5000},
+		&XDataset{"name": "Bob", "country": "US", "age": 30},
+	}
+}
+
+func names(dsc XDatasetCollectionDef) string {
+	result := []string{}
+	for i := 0; i < dsc.Count(); i++ {
+		ds, _ := dsc.Get(i)
+		name, _ := ds.GetString("name")
+		result = append(result, name)
+	}
+	return fmt.Sprint(result)
+}
+
+func TestXDatasetCollection_Ops(t *testing.T) {
+	c1 := XDatasetCollection(getOpsCollection())
+	collections := []interface {
+		XDatasetCollectionDef
+		Sort(...string)
+		Filter(func(XDatasetDef) bool) XDatasetCollectionDef
+		Map(func(XDatasetDef) XDatasetDef) XDatasetCollectionDef
+		Find(func(XDatasetDef) bool) (XDatasetDef, bool)
+		IndexOf(func(XDatasetDef) bool) int
+		GroupBy(string) XDatasetDef
+		Sum(string) float64
+		Avg(string) (float64, bool)
+		Min(string) (interface{}, bool)
+		Max(string) (interface{}, bool)
+		Distinct(string) []interface{}
+	}{
+		&c1,
+		&XDatasetCollectionTS{data: getOpsCollection()},
+	}
+	for _, dsc := range collections {
+		dsc.Sort("age") // "9" is a string: after the numbers
+		if r := names(dsc); r != "[Fred Bob Ana Juan]" {
+			t.Errorf("Error sorting by age: %s", r)
+		}
+		dsc.Sort("country", "-age", "name")
+		if r := names(dsc); r != "[Ana Juan Fred Bob]" {
+			t.Errorf("Error sorting by country, -age, name: %s", r)
+		}
+		dsc.Sort("hired") // missing values first
+		if r := names(dsc); r != "[Ana Bob Juan Fred]" {
+			t.Errorf("Error sorting by hired: %s", r)
+		}
+		dsc.Sort("-name")
+		if r := names(dsc); r != "[Juan Fred Bob Ana]" {
+			t.Errorf("Error sorting by -name: %s", r)
+		}
+
+		mx := dsc.Filter(func(ds XDatasetDef) bool {
+			country, _ := ds.GetString("country")
+			return country == "MX"
+		})
+		if r := names(mx); r != "[Juan Fred]" || dsc.Count() != 4 {
+			t.Errorf("Error filtering: %s", r)
+		}
+		upper := dsc.Map(func(ds XDatasetDef) XDatasetDef {
+			name, _ := ds.GetString("name")
+			if name == "Bob" {
+				return nil
+			}
+			return &XDataset{"name": name + "!"}
+		})
+		if r := names(upper); r != "[Juan! Fred! Ana!]" {
+			t.Errorf("Error mapping: %s", r)
+		}
+		old := func(ds XDatasetDef) bool {
+			age, _ := ds.GetInt("age")
+			return age > 40
+		}
+		if ds, ok := dsc.Find(old); !ok || dsc.IndexOf(old) != 3 {
+			t.Errorf("Error finding: %v", ds)
+		}
+		if _, ok := dsc.Find(func(ds XDatasetDef) bool { return false }); ok || dsc.IndexOf(func(ds XDatasetDef) bool { return false }) != -1 {
+			t.Error("Find should not find anything")
+		}
+
+		groups := dsc.GroupBy("country")
+		mxg, _ := groups.GetCollection("MX")
+		usg, _ := groups.GetCollection("US")
+		if names(mxg) != "[Juan Fred]" || names(usg) != "[Bob]" {
+			t.Errorf("Error grouping: %v", groups)
+		}
+
+		if sum := dsc.Sum("salary"); sum != 9700.5 {
+			t.Errorf("Error in Sum: %v", sum)
+		}
+		if avg, ok := dsc.Avg("age"); !ok || avg != 28.5 {
+			t.Errorf("Error in Avg: %v", avg)
+		}
+		if _, ok := dsc.Avg("unknown"); ok {
+			t.Error("Avg of no value should fail")
+		}
+		if min, ok := dsc.Min("age"); !ok || min != 30 {
+			t.Errorf("Error in Min: %v", min)
+		}
+		if max, ok := dsc.Max("hired"); !ok || max.(time.Time).Year() != 2020 {
+			t.Errorf("Error in Max: %v", max)
+		}
+		if _, ok := dsc.Max("unknown"); ok {
+			t.Error("Max of no value should fail")
+		}
+		if distinct := fmt.Sprint(dsc.Distinct("country")); distinct != "[MX US FR]" {
+			t.Errorf("Error in Distinct: %s", distinct)
+		}
+	}
+}
+
+func TestXDatasetCollection_SortMixed(t *testing.T) {
+	// the order is total on mixed values: by type first, then by value
+	values := []interface{}{"1a", math.NaN(), "10", 2, true, nil, 10.5, "2", false, math.Inf(-1)}
+	dsc := XDatasetCollection{}
+	for _, value := range values {
+		dsc.Push(&XDataset{"v": value})
+	}
+	for i := 0; i < 3; i++ {
+		dsc.Sort("v")
+		result := []string{}
+		for _, ds := range dsc {
+			v, _ := ds.Get("v")
+			result = append(result, fmt.Sprint(v))
+		}
+		if r := fmt.Sprint(result); r != "[<nil> false true NaN -Inf 2 10.5 10 1a 2]" {
+			t.Errorf("Error sorting mixed values: %s", r)
+			return
+		}
+	}
+	if min, _ := dsc.Min("v"); min != false {
+		t.Errorf("Error in Min of mixed values: %v", min)
+	}
+	if max, _ := dsc.Max("v"); max != "2" {
+		t.Errorf("Error in Max of mixed values: %v", max)
+	}
+}
@@ -0,0 +1,177 @@
+package xcore
+
+import (
+	"math/rand"
+	"testing"
+)
+
+type safeCollection interface {
+	XDatasetCollectionDef
+	TryShift() (XDatasetDef, bool)
+	TryPop() (XDatasetDef, bool)
+	Insert(int, XDatasetDef) error
+	RemoveAt(int) (XDatasetDef, error)
+	Set(int, XDatasetDef) error
+	Slice(int, int) XDatasetCollectionDef
+	Clear()
+}
+
+func TestXDatasetCollection_Safe(t *testing.T) {
+	for _, dsc := range []safeCollection{&XDatasetCollection{}, &XDatasetCollectionTS{}} {
+		if dsc.Shift() != nil || dsc.Pop() != nil {
+			t.Error("Shift and Pop on an empty collection should return nil")
+		}
+		if _, ok := dsc.TryShift(); ok {
+			t.Error("TryShift on an empty collection should fail")
+		}
+		if _, ok := dsc.TryPop(); ok {
+			t.Error("TryPop on an empty collection should fail")
+		}
+		if err := dsc.Insert(1, &XDataset{}); err != ErrCollectionIndex {
+			t.Error("Insert out of the collection should fail")
+		}
+		dsc.Insert(0, &XDataset{"v": 2})
+		dsc.Insert(0, &XDataset{"v": 0})
+		dsc.Insert(1, &XDataset{"v": 1})
+		dsc.Insert(3, &XDataset{"v": 3})
+		if err := dsc.Set(1, &XDataset{"v": 10}); err != nil {
+			t.Error(err)
+		}
+		if err := dsc.Set(4, &XDataset{}); err != ErrCollectionIndex {
+			t.Error("Set out of the collection should fail")
+		}
+		if ds, err := dsc.RemoveAt(2); err != nil || ds.String() != "xcore.XDataset{v:2}" {
+			t.Errorf("Error in RemoveAt: %v %v", ds, err)
+		}
+		if _, err := dsc.RemoveAt(-1); err != ErrCollectionIndex {
+			t.Error("RemoveAt out of the collection should fail")
+		}
+		if s := dsc.Slice(1, 10); s.Count() != 2 {
+			t.Errorf("Error in Slice: %v", s)
+		}
+		if s := dsc.Slice(2, 1); s.Count() != 0 {
+			t.Errorf("Error in Slice: %v", s)
+		}
+		if ds, ok := dsc.TryPop(); !ok || ds.String() != "xcore.XDataset{v:3}" {
+			t.Errorf("Error in TryPop: %v", ds)
+		}
+		if ds, ok := dsc.TryShift(); !ok || ds.String() != "xcore.XDataset{v:0}" {
+			t.Errorf("Error in TryShift: %v", ds)
+		}
+		dsc.Clear()
+		if dsc.Count() != 0 {
+			t.Errorf("Error in Clear: %v", dsc)
+		}
+	}
+
+	dsc := &XDatasetCollectionTS{data: []XDatasetDef{&XDataset{"name": "Fred"}}}
+	if _, ok := dsc.GetCollection("name"); ok {
+		t.Error("GetCollection on a scalar should fail")
+	}
+}
+
+// TestXDatasetCollection_RandomOperations runs random sequences of operations on the collections and compares them with a simple slice
+func TestXDatasetCollection_RandomOperations(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for run := 0; run < 200; run++ {
+		for _, dsc := range []safeCollection{&XDatasetCollection{}, &XDatasetCollectionTS{}} {
+			model := []int{}
+			for op := 0; op < 100; op++ {
+				index := r.Intn(len(model)+3) - 1
+				value := r.Intn(1000)
+				switch r.Intn(10) {
+				case 0:
+					dsc.Push(&XDataset{"v": value})
+					model = append(model, value)
+				case 1:
+					dsc.Unshift(&XDataset{"v": value})
+					model = append([]int{value}, model...)
+				case 2:
+					ds := dsc.Pop()
+					if len(model) == 0 {
+						if ds != nil {
+							t.Fatalf("Pop on an empty collection should return nil")
+						}
+						continue
+					}
+					if v, _ := ds.GetInt("v"); v != model[len(model)-1] {
+						t.Fatalf("Error in Pop: %d", v)
+					}
+					model = model[:len(model)-1]
+				case 3:
+					ds, ok := dsc.TryShift()
+					if ok != (len(model) > 0) {
+						t.Fatalf("Error in TryShift: %v", ok)
+					}
+					if ok {
+						if v, _ := ds.GetInt("v"); v != model[0] {
+							t.Fatalf("Error in TryShift: %d", v)
+						}
+						model = model[1:]
+					}
+				case 4:
+					err := dsc.Insert(index, &XDataset{"v": value})
+					if (err == nil) != (index >= 0 && index <= len(model)) {
+						t.Fatalf("Error in Insert %d: %v", index, err)
+					}
+					if err == nil {
+						model = append(model[:index], append([]int{value}, model[index:]...)...)
+					}
+				case 5:
+					ds, err := dsc.RemoveAt(index)
+					if (err == nil) != (index >= 0 && index < len(model)) {
+						t.Fatalf("Error in RemoveAt %d: %v", index, err)
+					}
+					if err == nil {
+						if v, _ := ds.GetInt("v"); v != model[index] {
+							t.Fatalf("Error in RemoveAt: %d", v)
+						}
+						model = append(model[:index], model[index+1:]...)
+					}
+				case 6:
+					err := dsc.Set(index, &XDataset{"v": value})
+					if (err == nil) != (index >= 0 && index < len(model)) {
+						t.Fatalf("Error in Set %d: %v", index, err)
+					}
+					if err == nil {
+						model[index] = value
+					}
+				case 7:
+					end := r.Intn(len(model)+3) - 1
+					s := dsc.Slice(index, end)
+					start, stop := index, end
+					if start < 0 {
+						start = 0
+					}
+					if stop > len(model) {
+						stop = len(model)
+					}
+					if stop < start {
+						stop = start
+					}
+					if s.Count() != stop-start {
+						t.Fatalf("Error in Slice %d %d: %d", index, end, s.Count())
+					}
+				case 8:
+					ds, ok := dsc.Get(index)
+					if ok != (index >= 0 && index < len(model)) {
+						t.Fatalf("Error in Get %d: %v", index, ok)
+					}
+					if ok {
+						if v, _ := ds.GetInt("v"); v != model[index] {
+							t.Fatalf("Error in Get: %d", v)
+						}
+					}
+				case 9:
+					if r.Intn(10) == 0 {
+						dsc.Clear()
+						model = []int{}
+					}
+				}
+				if dsc.Count() != len(model) {
+					t.Fatalf("Error in the size of the collection: %d != %d", dsc.Count(), len(model))
+				}
+			}
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package xcore
 
 import (
+	"encoding/json"
 	"fmt"
 	"strconv"
 	"sync"
@@ -13,6 +14,32 @@ type XDatasetCollectionTS struct {
 	data  []XDatasetDef
 }
 
+// MarshalJSON will encode the XDatasetCollectionTS and all its nested data into a JSON array
+func (dc *XDatasetCollectionTS) MarshalJSON() ([]byte, error) {
+	dc.mutex.RLock()
+	defer dc.mutex.RUnlock()
+	if dc.data == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(dc.data)
+}
+
+// UnmarshalJSON will decode the JSON array of objects into the XDatasetCollectionTS, replacing its content
+func (dc *XDatasetCollectionTS) UnmarshalJSON(data []byte) error {
+	temp := XDatasetCollection{}
+	if err := json.Unmarshal(data, &temp); err != nil {
+		return err
+	}
+	guarded := make([]XDatasetDef, 0, len(temp))
+	for _, ds := range temp {
+		guarded = append(guarded, guardOwnedDataset(ds))
+	}
+	dc.mutex.Lock()
+	dc.data = guarded
+	dc.mutex.Unlock()
+	return nil
+}
+
 // String will transform the XDataset into a readable string
 func (dc *XDatasetCollectionTS) String() string {
 	str := "XDatasetCollectionTS["
@@ -32,34 +59,95 @@ func (dc *XDatasetCollectionTS) GoString() string {
 
 // Unshift will adds a XDatasetDef at the beginning of the collection
 func (dc *XDatasetCollectionTS) Unshift(data XDatasetDef) {
+	data = guardDataset(data)
 	dc.mutex.Lock()
 	dc.data = append([]XDatasetDef{data}, dc.data...)
 	dc.mutex.Unlock()
 }
 
-// Shift will remove the element at the beginning of the collection
+// Shift will remove the element at the beginning of the collection. Returns nil if the collection is empty
 func (dc *XDatasetCollectionTS) Shift() XDatasetDef {
+	data, _ := dc.TryShift()
+	return data
+}
+
+// TryShift will remove the element at the beginning of the collection, or return false if the collection is empty
+func (dc *XDatasetCollectionTS) TryShift() (XDatasetDef, bool) {
 	dc.mutex.Lock()
+	defer dc.mutex.Unlock()
+	if len(dc.data) == 0 {
+		return nil, false
+	}
 	data := dc.data[0]
 	dc.data = dc.data[1:]
-	dc.mutex.Unlock()
-	return data
+	return data, true
 }
 
 // Push will adds a XDatasetDef at the end of the collection
 func (dc *XDatasetCollectionTS) Push(data XDatasetDef) {
+	data = guardDataset(data)
 	dc.mutex.Lock()
 	dc.data = append(dc.data, data)
 	dc.mutex.Unlock()
 }
 
-// Pop will remove the element at the end of the collection
+// Pop will remove the element at the end of the collection. Returns nil if the collection is empty
 func (dc *XDatasetCollectionTS) Pop() XDatasetDef {
+	data, _ := dc.TryPop()
+	return data
+}
+
+// TryPop will remove the element at the end of the collection, or return false if the collection is empty
+func (dc *XDatasetCollectionTS) TryPop() (XDatasetDef, bool) {
 	dc.mutex.Lock()
+	defer dc.mutex.Unlock()
+	if len(dc.data) == 0 {
+		return nil, false
+	}
 	data := dc.data[len(dc.data)-1]
 	dc.data = dc.data[:len(dc.data)-1]
+	return data, true
+}
+
+// Insert will insert the element at the index of the collection (0 to Count), moving the next elements
+func (dc *XDatasetCollectionTS) Insert(index int, data XDatasetDef) error {
+	data = guardDataset(data)
+	dc.mutex.Lock()
+	defer dc.mutex.Unlock()
+	return sliceInsert(&dc.data, index, data)
+}
+
+// RemoveAt will remove the element at the index of the collection and return it
+func (dc *XDatasetCollectionTS) RemoveAt(index int) (XDatasetDef, error) {
+	dc.mutex.Lock()
+	defer dc.mutex.Unlock()
+	return sliceRemoveAt(&dc.data, index)
+}
+
+// Set will replace the element at the index of the collection
+func (dc *XDatasetCollectionTS) Set(index int, data XDatasetDef) error {
+	data = guardDataset(data)
+	dc.mutex.Lock()
+	defer dc.mutex.Unlock()
+	if index < 0 || index >= len(dc.data) {
+		return ErrCollectionIndex
+	}
+	dc.data[index] = data
+	return nil
+}
+
+// Slice will build a new XDatasetCollectionTS with the elements from start to end (excluded) of the collection (see XDatasetCollection.Slice)
+func (dc *XDatasetCollectionTS) Slice(start int, end int) XDatasetCollectionDef {
+	dc.mutex.RLock()
+	defer dc.mutex.RUnlock()
+	return &XDatasetCollectionTS{data: sliceSlice(dc.data, start, end)}
+}
+
+// Clear will remove all the elements of the collection
+func (dc *XDatasetCollectionTS) Clear() {
+	dc.mutex.Lock()
+	dc.data = []XDatasetDef{}
 	dc.mutex.Unlock()
-	return data
 }
 
 // Count will return the quantity of elements into the collection
@@ -79,11 +167,57 @@ func (dc *XDatasetCollectionTS) Get(index int) (XDatasetDef, bool) {
 	return dc.data[index], true
 }
 
+// SetPath will set the data at the path "index>a>b" of the collection, creating the missing intermediate entries (see XDatasetCollection.SetPath)
+func (dc *XDatasetCollectionTS) SetPath(path string, data interface{}, collections bool) error {
+	dc.mutex.Lock()
+	defer dc.mutex.Unlock()
+	return dc.setPath(path, data, collections)
+}
+
+// setPath will set the data at the path "index>a>b", encapsulating the data and the created entries. The caller must have the write lock
+func (dc *XDatasetCollectionTS) setPath(path string, data interface{}, collections bool) error {
+	err := sliceSetPath(&dc.data, path, guardValue(data), collections)
+	head, _, _ := splitPath(path)
+	if index, e := strconv.Atoi(head); e == nil && index >= 0 && index < len(dc.data) {
+		dc.data[index] = guardOwnedDataset(dc.data[index])
+	}
+	return err
+}
+
+// Del will delete the data at the path "index>a>b" of the collection. If the path is only an index, the entry is removed from the collection
+func (dc *XDatasetCollectionTS) Del(path string) {
+	dc.mutex.Lock()
+	sliceDel(&dc.data, path)
+	dc.mutex.Unlock()
+}
+
+// Exists will check if there is a data at the path "index>a>b" of the collection
+func (dc *XDatasetCollectionTS) Exists(path string) bool {
+	dc.mutex.RLock()
+	defer dc.mutex.RUnlock()
+	return sliceExists(dc.data, path)
+}
+
 // GetData will retrieve the first available data identified by key from the collection ordered by index
+// The key may start with level selectors: "..>" for the parent level, "/>" for the root level and "@N>" for the level N of the collection.
 func (dc *XDatasetCollectionTS) GetData(key string) (interface{}, bool) {
 	dc.mutex.RLock()
 	l := len(dc.data) - 1
+	level, subkey, selected := scopeSelector(key, len(dc.data))
+	var dcs XDatasetDef
+	if selected && level >= 0 && level <= l {
+		dcs = dc.data[level]
+	}
 	dc.mutex.RUnlock()
+	if selected {
+		if dcs == nil {
+			return nil, false
+		}
+		if subkey == "" {
+			return dcs, true
+		}
+		return dcs.Get(subkey)
+	}
 	for i := l; i >= 0; i-- {
 		dc.mutex.RLock()
 		dcc := dc.data[i]
@@ -98,9 +232,8 @@ func (dc *XDatasetCollectionTS) GetData(key string) (interface{}, bool) {
 
 // GetDataString will retrieve the first available data identified by key from the collection ordered by index and return it as a string
 func (dc *XDatasetCollectionTS) GetDataString(key string) (string, bool) {
-	v, ok := dc.GetData(key)
-	if ok {
-		return fmt.Sprint(v), true
+	if val, ok := dc.GetData(key); ok {
+		return convertString(val)
 	}
 	return "", false
 }
@@ -108,9 +241,7 @@ func (dc *XDatasetCollectionTS) GetDataString(key string) (string, bool) {
 // GetDataBool will retrieve the first available data identified by key from the collection ordered by index and return it as a boolean
 func (dc *XDatasetCollectionTS) GetDataBool(key string) (bool, bool) {
 	if val, ok := dc.GetData(key); ok {
-		if val2, ok2 := val.(bool); ok2 {
-			return val2, true
-		}
+		return convertBool(val)
 	}
 	return false, false
 }
@@ -118,9 +249,7 @@ func (dc *XDatasetCollectionTS) GetDataBool(key string) (bool, bool) {
 // GetDataInt will retrieve the first available data identified by key from the collection ordered by index and return it as an integer
 func (dc *XDatasetCollectionTS) GetDataInt(key string) (int, bool) {
 	if val, ok := dc.GetData(key); ok {
-		if val2, ok2 := val.(int); ok2 {
-			return val2, true
-		}
+		return convertInt(val)
 	}
 	return 0, false
 }
@@ -128,9 +257,7 @@ func (dc *XDatasetCollectionTS) GetDataInt(key string) (int, bool) {
 // GetDataFloat will retrieve the first available data identified by key from the collection ordered by index and return it as a float
 func (dc *XDatasetCollectionTS) GetDataFloat(key string) (float64, bool) {
 	if val, ok := dc.GetData(key); ok {
-		if val2, ok2 := val.(float64); ok2 {
-			return val2, true
-		}
+		return convertFloat(val)
 	}
 	return 0, false
 }
@@ -138,9 +265,7 @@ func (dc *XDatasetCollectionTS) GetDataFloat(key string) (float64, bool) {
 // GetDataTime will retrieve the first available data identified by key from the collection ordered by index and return it as a time
 func (dc *XDatasetCollectionTS) GetDataTime(key string) (time.Time, bool) {
 	if val, ok := dc.GetData(key); ok {
-		if val2, ok2 := val.(time.Time); ok2 {
-			return val2, true
-		}
+		return convertTime(val)
 	}
 	return time.Time{}, false
 }
@@ -150,7 +275,9 @@ func (dc *XDatasetCollectionTS) GetCollection(key string) (XDatasetCollectionDef
 	v, ok := dc.GetData(key)
 	// Verify v IS actually a XDatasetCollectionDef to avoid the error
 	if ok {
-		return v.(XDatasetCollectionDef), true
+		if dsc, ok2 := v.(XDatasetCollectionDef); ok2 {
+			return dsc, true
+		}
 	}
 	return nil, false
 }
@@ -0,0 +1,241 @@
+package xcore
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// XDatasetConfig builds a configuration XDataset from layered sources: datasets (defaults), INI files, JSON files, environment variables and command-line flags.
+// The sources are merged in the order they are added, so each source overrides the previous ones (defaults, then files, then environment, then flags for instance).
+// The nested datasets are merged key by key, any other value (collections included) is replaced.
+// The source of each value is tracked and returned by Source.
+//
+// XDatasetConfig is not thread safe: build the configuration, then use the Dataset (encapsulated into a XDatasetTS or XDatasetAtomic if it is shared)
+type XDatasetConfig struct {
+	data    *XDataset
+	sources map[string]configSource
+	layers  []string
+}
+
+// configSource is the source of a value of the configuration, and the order of its layer
+type configSource struct {
+	name  string
+	layer int
+}
+
+// NewXDatasetConfig will build an empty configuration
+func NewXDatasetConfig() *XDatasetConfig {
+	return &XDatasetConfig{data: &XDataset{}, sources: map[string]configSource{}}
+}
+
+// add will merge the layer into the configuration, and track the source of all its values. source returns the name of the source of a path
+func (c *XDatasetConfig) add(name string, layer XDatasetDef, source func(path string) string) error {
+	if err := mergeDataset(c.data, layer, nil); err != nil {
+		return err
+	}
+	configLeaves("", layer, func(path string) {
+		// the values previously set below or above the path are replaced
+		for key := range c.sources {
+			if strings.HasPrefix(key, path+">") || strings.HasPrefix(path, key+">") {
+				delete(c.sources, key)
+			}
+		}
+		c.sources[path] = configSource{name: source(path), layer: len(c.layers)}
+	})
+	c.layers = append(c.layers, name)
+	return nil
+}
+
+// configLeaves will call the function with the path "a>b>c" of each value of the dataset that is not a nested dataset (or is an empty one)
+func configLeaves(prefix string, ds XDatasetDef, fn func(path string)) {
+	keys, _ := datasetKeys(ds)
+	for _, key := range keys {
+		value, _ := datasetKey(ds, key)
+		path := prefix + key
+		if sub, ok := value.(XDatasetDef); ok {
+			if subkeys, ok := datasetKeys(sub); ok && len(subkeys) > 0 {
+				configLeaves(path+">", sub, fn)
+				continue
+			}
+		}
+		fn(path)
+	}
+}
+
+// AddDataset will merge the dataset into the configuration (defaults for instance). name is the source of its values
+func (c *XDatasetConfig) AddDataset(name string, ds XDatasetDef) error {
+	return c.add(name, ds, func(string) string { return name })
+}
+
+// AddINIString will merge the INI data into the configuration. name is the source of its values.
+//
+// Each line is a key=value, with the format of the XLanguage files: the key and the value are trimmed, the empty lines,
+// the lines without = and the comments (starting with # or ;) are ignored. The values are strings, the surrounding double quotes are removed.
+// A line [section] puts the next keys into the nested dataset section, and [] returns to the root. The sections and the keys may be paths "a>b>c"
+func (c *XDatasetConfig) AddINIString(name string, data string) error {
+	layer := &XDataset{}
+	section := ""
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if len(text) == 0 || text[0] == '#' || text[0] == ';' {
+			continue
+		}
+		if text[0] == '[' {
+			if text[len(text)-1] != ']' {
+				return errors.New("Error: the section of the line " + strconv.Itoa(line) + " of " + name + " is not closed")
+			}
+			section = strings.TrimSpace(text[1 : len(text)-1])
+			if _, ok := layer.Get(section); !ok && section != "" {
+				if err := setDatasetPath(layer, section, &XDataset{}, false); err != nil {
+					return errors.New("Error: line " + strconv.Itoa(line) + " of " + name + ": " + err.Error())
+				}
+			}
+			continue
+		}
+		posequal := strings.Index(text, "=")
+		if posequal < 0 {
+			continue
+		}
+		key := strings.TrimSpace(text[:posequal])
+		if len(key) == 0 {
+			continue
+		}
+		value := strings.TrimSpace(text[posequal+1:])
+		if len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"' {
+			value = value[1 : len(value)-1]
+		}
+		if section != "" {
+			key = section + ">" + key
+		}
+		if err := setDatasetPath(layer, key, value, false); err != nil {
+			return errors.New("Error: line " + strconv.Itoa(line) + " of " + name + ": " + err.Error())
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return c.AddDataset(name, layer)
+}
+
+// AddINIFile will merge the INI file into the configuration (see AddINIString). The file name is the source of its values
+func (c *XDatasetConfig) AddINIFile(file string) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	return c.AddINIString(file, string(data))
+}
+
+// AddJSONString will merge the JSON object into the configuration. name is the source of its values.
+// The objects are nested datasets and the arrays of objects are collections (see XDataset.UnmarshalJSON)
+func (c *XDatasetConfig) AddJSONString(name string, data string) error {
+	layer := &XDataset{}
+	if err := json.Unmarshal([]byte(data), layer); err != nil {
+		return err
+	}
+	return c.AddDataset(name, layer)
+}
+
+// AddJSONFile will merge the JSON file into the configuration (see AddJSONString). The file name is the source of its values
+func (c *XDatasetConfig) AddJSONFile(file string) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	return c.AddJSONString(file, string(data))
+}
+
+// AddEnv will merge the environment variables starting with the prefix into the configuration.
+// The prefix is removed and the rest of the name is the key in lower case, with "__" (or ">") as the separator of the path:
+// with the prefix "APP_", APP_DB__HOST=localhost is the value "localhost" of the path "db>host". The values are strings.
+// The source of each value is "env:" and the name of the variable
+func (c *XDatasetConfig) AddEnv(prefix string) error {
+	layer := &XDataset{}
+	names := map[string]string{}
+	environ := os.Environ()
+	sort.Strings(environ)
+	for _, variable := range environ {
+		posequal := strings.Index(variable, "=")
+		if posequal < 0 || !strings.HasPrefix(variable[:posequal], prefix) {
+			continue
+		}
+		name := variable[:posequal]
+		key := strings.ToLower(strings.ReplaceAll(name[len(prefix):], "__", ">"))
+		if key == "" {
+			continue
+		}
+		if err := setDatasetPath(layer, key, variable[posequal+1:], false); err != nil {
+			return errors.New("Error: the environment variable " + name + ": " + err.Error())
+		}
+		names[key] = name
+	}
+	return c.add("env:"+prefix, layer, func(path string) string { return "env:" + names[path] })
+}
+
+// AddFlags will merge the flags of the parsed flag set into the configuration. Only the flags set on the command line are used, not the defaults.
+// The name of the flag is the key, with "." (or ">") as the separator of the path: -db.host=localhost is the value "localhost" of the path "db>host".
+// The values have the type of the flag (int, bool, string...). The source of each value is "flag:-" and the name of the flag
+func (c *XDatasetConfig) AddFlags(fs *flag.FlagSet) error {
+	layer := &XDataset{}
+	names := map[string]string{}
+	var err error
+	fs.Visit(func(f *flag.Flag) {
+		if err != nil {
+			return
+		}
+		var value interface{} = f.Value.String()
+		if getter, ok := f.Value.(flag.Getter); ok {
+			value = getter.Get()
+		}
+		key := strings.ReplaceAll(f.Name, ".", ">")
+		if e := setDatasetPath(layer, key, value, false); e != nil {
+			err = errors.New("Error: the flag -" + f.Name + ": " + e.Error())
+		}
+		names[key] = f.Name
+	})
+	if err != nil {
+		return err
+	}
+	return c.add("flags", layer, func(path string) string { return "flag:-" + names[path] })
+}
+
+// Dataset will return the merged configuration
+func (c *XDatasetConfig) Dataset() *XDataset {
+	return c.data
+}
+
+// Source will return the source of the value of the path "a>b>c": the name of the dataset, the file, "env:VARIABLE" or "flag:-name".
+// For a nested dataset, it is the source of its last added value. Returns "" if the path does not exist
+func (c *XDatasetConfig) Source(key string) string {
+	if source, ok := c.sources[key]; ok {
+		return source.name
+	}
+	last := configSource{layer: -1}
+	for path, source := range c.sources {
+		if strings.HasPrefix(path, key+">") && source.layer > last.layer {
+			last = source
+		}
+	}
+	return last.name
+}
+
+// Sources will return the source of each value of the configuration, by path "a>b>c"
+func (c *XDatasetConfig) Sources() map[string]string {
+	sources := map[string]string{}
+	for path, source := range c.sources {
+		sources[path] = source.name
+	}
+	return sources
+}
+
+// Layers will return the names of the sources added to the configuration, in order of precedence (the last one wins)
+func (c *XDatasetConfig) Layers() []string {
+	return append([]string{}, c.layers...)
+}
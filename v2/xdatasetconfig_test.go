@@ -0,0 +1,106 @@
+package xcore
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestXDatasetConfig(t *testing.T) {
+	dir := t.TempDir()
+	ini := filepath.Join(dir, "app.ini")
+	os.WriteFile(ini, []byte(`# application
+name = "My site"
+debug = false
+
+[db]
+host = localhost
+port = 5432
+; comment
+[cache>redis]
+host = redis
+[db]
+user = admin
+[]
+mode = ini
+`), 0644)
+	js := filepath.Join(dir, "app.json")
+	os.WriteFile(js, []byte(`{"db": {"port": 3306, "pool": {"size": 10}}, "servers": [{"host": "a"}, {"host": "b"}]}`), 0644)
+
+	t.Setenv("XCORETEST_DB__HOST", "remote")
+	t.Setenv("XCORETEST_CACHE>REDIS>PORT", "6379")
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Int("db.port", 1000, "port")
+	fs.Bool("debug", false, "debug")
+	fs.String("mode", "default", "mode")
+	if err := fs.Parse([]string{"-db.port=7000", "-debug"}); err != nil {
+		t.Fatal(err)
+	}
+
+	config := NewXDatasetConfig()
+	if err := config.AddDataset("defaults", &XDataset{"mode": "default", "db": &XDataset{"host": "127.0.0.1", "timeout": 30}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.AddINIFile(ini); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.AddJSONFile(js); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.AddEnv("XCORETEST_"); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.AddFlags(fs); err != nil {
+		t.Fatal(err)
+	}
+
+	ds := config.Dataset()
+	if ds.String() != "xcore.XDataset{cache:xcore.XDataset{redis:xcore.XDataset{host:redis port:6379}} db:xcore.XDataset{host:remote pool:xcore.XDataset{size:10} port:7000 timeout:30 user:admin} debug:true mode:ini name:My site servers:XDatasetCollection[0:xcore.XDataset{host:a} 1:xcore.XDataset{host:b} ]}" {
+		t.Errorf("Error in the configuration: %s", ds)
+	}
+	if port, ok := ds.GetInt("db>port"); !ok || port != 7000 {
+		t.Errorf("Error in the type of the flag: %v", ds)
+	}
+
+	sources := map[string]string{
+		"mode":             ini,
+		"name":             ini,
+		"debug":            "flag:-debug",
+		"db>host":          "env:XCORETEST_DB__HOST",
+		"db>port":          "flag:-db.port",
+		"db>timeout":       "defaults",
+		"db>pool>size":     js,
+		"db>pool":          js,
+		"db":               "flag:-db.port",
+		"cache>redis>port": "env:XCORETEST_CACHE>REDIS>PORT",
+		"cache>redis>host": ini,
+		"servers":          js,
+		"unknown":          "",
+	}
+	for key, source := range sources {
+		if config.Source(key) != source {
+			t.Errorf("Error in the source of %s: %s, %s expected", key, config.Source(key), source)
+		}
+	}
+	if len(config.Sources()) != 11 || len(config.Layers()) != 5 || config.Layers()[3] != "env:XCORETEST_" {
+		t.Errorf("Error in the sources: %v %v", config.Sources(), config.Layers())
+	}
+
+	// a value replaces the nested dataset and its sources
+	config.AddJSONString("override", `{"db": "none"}`)
+	if config.Source("db") != "override" || config.Source("db>host") != "" || len(config.Sources()) != 7 {
+		t.Errorf("Error in the replaced sources: %v", config.Sources())
+	}
+
+	if err := NewXDatasetConfig().AddINIString("bad", "[db\nhost=a"); err == nil {
+		t.Error("Error: a section not closed should fail")
+	}
+	if err := NewXDatasetConfig().AddINIString("bad", "db=a\n[db]\nhost=a"); err == nil {
+		t.Error("Error: a section on a value should fail")
+	}
+	if err := NewXDatasetConfig().AddJSONFile(filepath.Join(dir, "unknown.json")); err == nil {
+		t.Error("Error: a missing file should fail")
+	}
+}
@@ -0,0 +1,297 @@
+package xcore
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// XDatasetTimeLayouts are the layouts used by GetTime and GetTimeCollection to convert the strings to time.Time, tried in order
+var XDatasetTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02",
+}
+
+// convertString will convert the value to string with the rules of GetString: nil is an empty string, anything else is printed
+func convertString(val interface{}) (string, bool) {
+	if val == nil {
+		return "", true
+	}
+	return fmt.Sprint(val), true
+}
+
+// convertBool will convert the value to bool with the rules of GetBool: the numbers are true if != 0, the time if not zero,
+// the strings are parsed as booleans ("true", "1", "f"...) or numbers (empty is false), and anything else is true if not nil
+func convertBool(val interface{}) (bool, bool) {
+	switch v := val.(type) {
+	case bool:
+		return v, true
+	case time.Time:
+		return !v.Equal(time.Time{}), true
+	case string:
+		str := strings.TrimSpace(v)
+		if str == "" {
+			return false, true
+		}
+		if b, err := strconv.ParseBool(str); err == nil {
+			return b, true
+		}
+		if f, err := strconv.ParseFloat(str, 64); err == nil {
+			return f != 0, true
+		}
+		return false, false
+	case json.Number:
+		f, err := v.Float64()
+		return f != 0, err == nil
+	}
+	if f, ok := numberValue(val); ok {
+		return f != 0, true
+	}
+	return val != nil, true
+}
+
+// convertInt will convert the value to int with the rules of GetInt: the booleans are 0/1, the floats are truncated,
+// the time is the unix time in seconds (0 for the zero time) and the strings are parsed as numbers
+func convertInt(val interface{}) (int, bool) {
+	switch v := val.(type) {
+	case bool:
+		if v {
+			return 1, true
+		}
+		return 0, true
+	case int:
+		return v, true
+	case int64:
+		return int(v), true
+	case uint64:
+		return int(v), true
+	case float32:
+		return int(v), true
+	case float64:
+		return int(v), true
+	case time.Time:
+		if v.Equal(time.Time{}) {
+			return 0, true
+		}
+		return int(v.Unix()), true
+	case string:
+		str := strings.TrimSpace(v)
+		if i, err := strconv.ParseInt(str, 10, 64); err == nil {
+			return int(i), true
+		}
+		if f, err := strconv.ParseFloat(str, 64); err == nil {
+			return int(f), true
+		}
+		return 0, false
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return int(i), true
+		}
+		if f, err := v.Float64(); err == nil {
+			return int(f), true
+		}
+		return 0, false
+	}
+	if f, ok := numberValue(val); ok {
+		return int(f), true
+	}
+	return 0, false
+}
+
+// convertFloat will convert the value to float64 with the rules of GetFloat: the booleans are 0/1,
+// the time is the unix time in seconds (0 for the zero time) and the strings are parsed as numbers
+func convertFloat(val interface{}) (float64, bool) {
+	switch v := val.(type) {
+	case bool:
+		if v {
+			return 1.0, true
+		}
+		return 0.0, true
+	case time.Time:
+		if v.Equal(time.Time{}) {
+			return 0, true
+		}
+		return float64(v.Unix()), true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return f, err == nil
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	}
+	return numberValue(val)
+}
+
+// convertTime will convert the value to time.Time with the rules of GetTime: the strings are parsed with the XDatasetTimeLayouts,
+// and the numbers are the unix time in seconds (0 is the zero time), in UTC
+func convertTime(val interface{}) (time.Time, bool) {
+	switch v := val.(type) {
+	case time.Time:
+		return v, true
+	case string:
+		str := strings.TrimSpace(v)
+		for _, layout := range XDatasetTimeLayouts {
+			if t, err := time.Parse(layout, str); err == nil {
+				return t, true
+			}
+		}
+		return time.Time{}, false
+	case json.Number:
+		f, err := v.Float64()
+		if err != nil {
+			return time.Time{}, false
+		}
+		return unixTime(f), true
+	}
+	if f, ok := numberValue(val); ok {
+		return unixTime(f), true
+	}
+	return time.Time{}, false
+}
+
+// unixTime will build the UTC time of the unix time in seconds, with the decimals as nanoseconds. 0 is the zero time
+func unixTime(seconds float64) time.Time {
+	if seconds == 0 {
+		return time.Time{}
+	}
+	sec, frac := math.Modf(seconds)
+	return time.Unix(int64(sec), int64(frac*1e9)).UTC()
+}
+
+// numberValue will convert any integer or float type to float64
+func numberValue(val interface{}) (float64, bool) {
+	switch v := val.(type) {
+	case int:
+		return float64(v), true
+	case int8:
+		return float64(v), true
+	case int16:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case uint8:
+		return float64(v), true
+	case uint16:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
+// convertSlice will call the converter with each element of a slice or array (of any type).
+// Returns false if the value is not a slice or an array
+func convertSlice(val interface{}, converter func(interface{})) bool {
+	rv := reflect.ValueOf(val)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return false
+	}
+	for i := 0; i < rv.Len(); i++ {
+		converter(rv.Index(i).Interface())
+	}
+	return true
+}
+
+// convertStringCollection will convert the value to []string, element by element with the rules of GetString
+func convertStringCollection(val interface{}) ([]string, bool) {
+	if v, ok := val.([]string); ok {
+		return v, true
+	}
+	result := []string{}
+	ok := convertSlice(val, func(e interface{}) {
+		s, _ := convertString(e)
+		result = append(result, s)
+	})
+	if !ok {
+		return nil, false
+	}
+	return result, true
+}
+
+// convertBoolCollection will convert the value to []bool, element by element with the rules of GetBool
+func convertBoolCollection(val interface{}) ([]bool, bool) {
+	if v, ok := val.([]bool); ok {
+		return v, true
+	}
+	result := []bool{}
+	valid := true
+	ok := convertSlice(val, func(e interface{}) {
+		b, ok := convertBool(e)
+		valid = valid && ok
+		result = append(result, b)
+	})
+	if !ok || !valid {
+		return nil, false
+	}
+	return result, true
+}
+
+// convertIntCollection will convert the value to []int, element by element with the rules of GetInt
+func convertIntCollection(val interface{}) ([]int, bool) {
+	if v, ok := val.([]int); ok {
+		return v, true
+	}
+	result := []int{}
+	valid := true
+	ok := convertSlice(val, func(e interface{}) {
+		i, ok := convertInt(e)
+		valid = valid && ok
+		result = append(result, i)
+	})
+	if !ok || !valid {
+		return nil, false
+	}
+	return result, true
+}
+
+// convertFloatCollection will convert the value to []float64, element by element with the rules of GetFloat
+func convertFloatCollection(val interface{}) ([]float64, bool) {
+	if v, ok := val.([]float64); ok {
+		return v, true
+	}
+	result := []float64{}
+	valid := true
+	ok := convertSlice(val, func(e interface{}) {
+		f, ok := convertFloat(e)
+		valid = valid && ok
+		result = append(result, f)
+	})
+	if !ok || !valid {
+		return nil, false
+	}
+	return result, true
+}
+
+// convertTimeCollection will convert the value to []time.Time, element by element with the rules of GetTime
+func convertTimeCollection(val interface{}) ([]time.Time, bool) {
+	if v, ok := val.([]time.Time); ok {
+		return v, true
+	}
+	result := []time.Time{}
+	valid := true
+	ok := convertSlice(val, func(e interface{}) {
+		t, ok := convertTime(e)
+		valid = valid && ok
+		result = append(result, t)
+	})
+	if !ok || !valid {
+		return nil, false
+	}
+	return result, true
+}
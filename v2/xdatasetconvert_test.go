@@ -0,0 +1,141 @@
+package xcore
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestXDataset_Conversions(t *testing.T) {
+	t1, _ := time.Parse(time.RFC3339, "2020-01-01T12:00:00Z")
+	t2, _ := time.Parse("2006-01-02", "2020-01-01")
+	sources := map[string]interface{}{
+		"nil":        nil,
+		"true":       true,
+		"false":      false,
+		"int":        12,
+		"int0":       0,
+		"int8":       int8(-12),
+		"uint16":     uint16(12),
+		"float32":    float32(12.5),
+		"float64":    1577880000.5,
+		"time":       t1,
+		"timezero":   time.Time{},
+		"strint":     " 12 ",
+		"strfloat":   "12.5",
+		"strbool":    "true",
+		"strempty":   "",
+		"strtime":    "2020-01-01T12:00:00Z",
+		"strdate":    "2020-01-01",
+		"strtext":    "abc",
+		"jsonint":    json.Number("12"),
+		"jsonfloat":  json.Number("12.5"),
+		"dataset":    &XDataset{},
+		"collection": &XDatasetCollection{},
+	}
+	type result struct {
+		value interface{}
+		ok    bool
+	}
+	// expected results of GetString, GetBool, GetInt, GetFloat, GetTime for each source
+	matrix := map[string][5]result{
+		"nil":        {{"", true}, {false, true}, {0, false}, {0.0, false}, {time.Time{}, false}},
+		"true":       {{"true", true}, {true, true}, {1, true}, {1.0, true}, {time.Time{}, false}},
+		"false":      {{"false", true}, {false, true}, {0, true}, {0.0, true}, {time.Time{}, false}},
+		"int":        {{"12", true}, {true, true}, {12, true}, {12.0, true}, {time.Unix(12, 0).UTC(), true}},
+		"int0":       {{"0", true}, {false, true}, {0, true}, {0.0, true}, {time.Time{}, true}},
+		"int8":       {{"-12", true}, {true, true}, {-12, true}, {-12.0, true}, {time.Unix(-12, 0).UTC(), true}},
+		"uint16":     {{"12", true}, {true, true}, {12, true}, {12.0, true}, {time.Unix(12, 0).UTC(), true}},
+		"float32":    {{"12.5", true}, {true, true}, {12, true}, {12.5, true}, {time.Unix(12, 5e8).UTC(), true}},
+		"float64":    {{"1.5778800005e+09", true}, {true, true}, {1577880000, true}, {1577880000.5, true}, {time.Unix(1577880000, 5e8).UTC(), true}},
+		"time":       {{"2020-01-01 12:00:00 +0000 UTC", true}, {true, true}, {1577880000, true}, {1577880000.0, true}, {t1, true}},
+		"timezero":   {{"0001-01-01 00:00:00 +0000 UTC", true}, {false, true}, {0, true}, {0.0, true}, {time.Time{}, true}},
+		"strint":     {{" 12 ", true}, {true, true}, {12, true}, {12.0, true}, {time.Time{}, false}},
+		"strfloat":   {{"12.5", true}, {true, true}, {12, true}, {12.5, true}, {time.Time{}, false}},
+		"strbool":    {{"true", true}, {true, true}, {0, false}, {0.0, false}, {time.Time{}, false}},
+		"strempty":   {{"", true}, {false, true}, {0, false}, {0.0, false}, {time.Time{}, false}},
+		"strtime":    {{"2020-01-01T12:00:00Z", true}, {false, false}, {0, false}, {0.0, false}, {t1, true}},
+		"strdate":    {{"2020-01-01", true}, {false, false}, {0, false}, {0.0, false}, {t2, true}},
+		"strtext":    {{"abc", true}, {false, false}, {0, false}, {0.0, false}, {time.Time{}, false}},
+		"jsonint":    {{"12", true}, {true, true}, {12, true}, {12.0, true}, {time.Unix(12, 0).UTC(), true}},
+		"jsonfloat":  {{"12.5", true}, {true, true}, {12, true}, {12.5, true}, {time.Unix(12, 5e8).UTC(), true}},
+		"dataset":    {{"xcore.XDataset{}", true}, {true, true}, {0, false}, {0.0, false}, {time.Time{}, false}},
+		"collection": {{"XDatasetCollection[]", true}, {true, true}, {0, false}, {0.0, false}, {time.Time{}, false}},
+	}
+
+	ds := &XDataset{}
+	dsc := &XDatasetCollection{ds}
+	for key, value := range sources {
+		ds.Set(key, value)
+	}
+	for key, expected := range matrix {
+		getters := [5]func(string) (interface{}, bool){
+			func(k string) (interface{}, bool) { return ds.GetString(k) },
+			func(k string) (interface{}, bool) { return ds.GetBool(k) },
+			func(k string) (interface{}, bool) { return ds.GetInt(k) },
+			func(k string) (interface{}, bool) { return ds.GetFloat(k) },
+			func(k string) (interface{}, bool) { return ds.GetTime(k) },
+		}
+		datagetters := [5]func(string) (interface{}, bool){
+			func(k string) (interface{}, bool) { return dsc.GetDataString(k) },
+			func(k string) (interface{}, bool) { return dsc.GetDataBool(k) },
+			func(k string) (interface{}, bool) { return dsc.GetDataInt(k) },
+			func(k string) (interface{}, bool) { return dsc.GetDataFloat(k) },
+			func(k string) (interface{}, bool) { return dsc.GetDataTime(k) },
+		}
+		for i, getter := range getters {
+			for _, get := range []func(string) (interface{}, bool){getter, datagetters[i]} {
+				value, ok := get(key)
+				if ok != expected[i].ok || (ok && !reflect.DeepEqual(value, expected[i].value)) {
+					t.Errorf("Error converting %s to %T: %#v %v", key, expected[i].value, value, ok)
+				}
+			}
+		}
+	}
+}
+
+func TestXDataset_CollectionConversions(t *testing.T) {
+	ds := &XDataset{
+		"mixed":   []interface{}{1, "2", 3.5, true, json.Number("5")},
+		"strings": []string{"1", "0", "true"},
+		"ints":    []int{0, 1577880000},
+		"invalid": []interface{}{1, "abc"},
+		"scalar":  "1",
+	}
+	tests := []struct {
+		key      string
+		get      func(string) (interface{}, bool)
+		expected string
+	}{
+		{"mixed", func(k string) (interface{}, bool) { return ds.GetStringCollection(k) }, "[1 2 3.5 true 5]"},
+		{"mixed", func(k string) (interface{}, bool) { return ds.GetIntCollection(k) }, "[1 2 3 1 5]"},
+		{"mixed", func(k string) (interface{}, bool) { return ds.GetFloatCollection(k) }, "[1 2 3.5 1 5]"},
+		{"mixed", func(k string) (interface{}, bool) { return ds.GetBoolCollection(k) }, "[true true true true true]"},
+		{"strings", func(k string) (interface{}, bool) { return ds.GetBoolCollection(k) }, "[true false true]"},
+		{"strings", func(k string) (interface{}, bool) { return ds.GetIntCollection(k) }, "<nil> false"},
+		{"ints", func(k string) (interface{}, bool) { return ds.GetTimeCollection(k) }, "[0001-01-01 00:00:00 +0000 UTC 2020-01-01 12:00:00 +0000 UTC]"},
+		{"ints", func(k string) (interface{}, bool) { return ds.GetStringCollection(k) }, "[0 1577880000]"},
+		{"invalid", func(k string) (interface{}, bool) { return ds.GetIntCollection(k) }, "<nil> false"},
+		{"scalar", func(k string) (interface{}, bool) { return ds.GetIntCollection(k) }, "<nil> false"},
+	}
+	for _, test := range tests {
+		value, ok := test.get(test.key)
+		str := fmt.Sprint(value)
+		if !ok {
+			str = "<nil> false"
+		}
+		if str != test.expected {
+			t.Errorf("Error converting the collection %s: %s", test.key, str)
+		}
+	}
+
+	layouts := XDatasetTimeLayouts
+	XDatasetTimeLayouts = []string{"02/01/2006"}
+	defer func() { XDatasetTimeLayouts = layouts }()
+	ds.Set("date", "31/12/2020")
+	if v, ok := ds.GetTime("date"); !ok || v.Year() != 2020 || v.Month() != 12 {
+		t.Errorf("Error converting the time with the layout: %v", v)
+	}
+}
@@ -0,0 +1,219 @@
+package xcore
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"time"
+)
+
+// ErrDatasetKey is the error of the generic accessors when the key does not exist into the dataset
+var ErrDatasetKey = errors.New("Error: the key does not exist into the dataset")
+
+// ErrDatasetConvert is the error of the generic accessors when the value cannot be converted to the asked type
+var ErrDatasetConvert = errors.New("Error: the value cannot be converted to the asked type")
+
+// convertAs will convert the value to the type T with the rules of GetString, GetBool, GetInt, GetFloat, GetTime and the Get*Collection.
+// The other integer and float types are converted with the rules of GetInt and GetFloat, the integers out of the range of the type (or negative for the unsigned types) cannot be converted.
+// Any other type is a type assertion of the value
+func convertAs[T any](val interface{}) (T, bool) {
+	var result T
+	ok := true
+	switch p := any(&result).(type) {
+	case *string:
+		*p, ok = convertString(val)
+	case *bool:
+		*p, ok = convertBool(val)
+	case *int:
+		*p, ok = convertInt(val)
+	case *int8:
+		var i int64
+		i, ok = convertIntRange(val, math.MinInt8, math.MaxInt8)
+		*p = int8(i)
+	case *int16:
+		var i int64
+		i, ok = convertIntRange(val, math.MinInt16, math.MaxInt16)
+		*p = int16(i)
+	case *int32:
+		var i int64
+		i, ok = convertIntRange(val, math.MinInt32, math.MaxInt32)
+		*p = int32(i)
+	case *int64:
+		var i int
+		i, ok = convertInt(val)
+		*p = int64(i)
+	case *uint:
+		var i int64
+		i, ok = convertIntRange(val, 0, math.MaxInt64)
+		*p = uint(i)
+	case *uint8:
+		var i int64
+		i, ok = convertIntRange(val, 0, math.MaxUint8)
+		*p = uint8(i)
+	case *uint16:
+		var i int64
+		i, ok = convertIntRange(val, 0, math.MaxUint16)
+		*p = uint16(i)
+	case *uint32:
+		var i int64
+		i, ok = convertIntRange(val, 0, math.MaxUint32)
+		*p = uint32(i)
+	case *uint64:
+		var i int64
+		i, ok = convertIntRange(val, 0, math.MaxInt64)
+		*p = uint64(i)
+	case *float32:
+		var f float64
+		f, ok = convertFloat(val)
+		*p = float32(f)
+	case *float64:
+		*p, ok = convertFloat(val)
+	case *time.Time:
+		*p, ok = convertTime(val)
+	case *[]string:
+		*p, ok = convertStringCollection(val)
+	case *[]bool:
+		*p, ok = convertBoolCollection(val)
+	case *[]int:
+		*p, ok = convertIntCollection(val)
+	case *[]float64:
+		*p, ok = convertFloatCollection(val)
+	case *[]time.Time:
+		*p, ok = convertTimeCollection(val)
+	default:
+		result, ok = val.(T)
+	}
+	if !ok {
+		var zero T
+		return zero, false
+	}
+	return result, true
+}
+
+// convertIntRange will convert the value with the rules of GetInt, and check it is into the range min to max
+func convertIntRange(val interface{}, min int64, max int64) (int64, bool) {
+	i, ok := convertInt(val)
+	if !ok || int64(i) < min || int64(i) > max {
+		return 0, false
+	}
+	return int64(i), true
+}
+
+// getAs will read the value of the key (or path "a>b>c") of the dataset as the type T, and return ErrDatasetKey or ErrDatasetConvert if it fails
+func getAs[T any](ds XDatasetDef, key string) (T, error) {
+	val, ok := ds.Get(key)
+	if !ok {
+		var zero T
+		return zero, fmt.Errorf("%w: %s", ErrDatasetKey, key)
+	}
+	result, ok := convertAs[T](val)
+	if !ok {
+		return result, fmt.Errorf("%w: %s (%T to %v)", ErrDatasetConvert, key, val, reflect.TypeOf((*T)(nil)).Elem())
+	}
+	return result, nil
+}
+
+// GetAs will read the value of the key (or path "a>b>c") of the dataset as the type T.
+// The value is converted with the rules of GetString, GetBool, GetInt, GetFloat, GetTime and the Get*Collection (the other integer and float types with the rules of GetInt and GetFloat).
+// Any other type (XDatasetDef, XDatasetCollectionDef, a struct...) is a type assertion of the value.
+// Returns false if the key does not exist or the value cannot be converted
+//
+//	port, ok := xcore.GetAs[int](config, "db>port")
+func GetAs[T any](ds XDatasetDef, key string) (T, bool) {
+	result, err := getAs[T](ds, key)
+	return result, err == nil
+}
+
+// GetOr will read the value of the key (or path "a>b>c") of the dataset as the type T (see GetAs), or return the default value if the key does not exist or the value cannot be converted
+//
+//	port := xcore.GetOr(config, "db>port", 5432)
+func GetOr[T any](ds XDatasetDef, key string, def T) T {
+	if result, err := getAs[T](ds, key); err == nil {
+		return result
+	}
+	return def
+}
+
+// MustGet will read the value of the key (or path "a>b>c") of the dataset as the type T (see GetAs).
+// It panics if the key does not exist or the value cannot be converted: use it only for the values that must be there (checked by a schema for instance)
+func MustGet[T any](ds XDatasetDef, key string) T {
+	result, err := getAs[T](ds, key)
+	if err != nil {
+		panic(err)
+	}
+	return result
+}
+
+// XDatasetGetter reads many values of a dataset and accumulates the errors, so they are checked once at the end of the batch of reads.
+// A missing key or a value that cannot be converted returns the zero value of the type and adds an error (wrapping ErrDatasetKey or ErrDatasetConvert)
+//
+//	g := xcore.NewXDatasetGetter(config)
+//	host := g.String("db>host")
+//	port := g.Int("db>port")
+//	if err := g.Err(); err != nil {
+//	  return err
+//	}
+type XDatasetGetter struct {
+	ds   XDatasetDef
+	errs []error
+}
+
+// NewXDatasetGetter will build a XDatasetGetter on the dataset
+func NewXDatasetGetter(ds XDatasetDef) *XDatasetGetter {
+	return &XDatasetGetter{ds: ds}
+}
+
+// GetterAs will read the value of the key (or path "a>b>c") as the type T (see GetAs) with the getter, adding the error to the getter if it fails
+func GetterAs[T any](g *XDatasetGetter, key string) T {
+	result, err := getAs[T](g.ds, key)
+	if err != nil {
+		g.errs = append(g.errs, err)
+	}
+	return result
+}
+
+// String will read the value of the key as a string (see GetString)
+func (g *XDatasetGetter) String(key string) string {
+	return GetterAs[string](g, key)
+}
+
+// Bool will read the value of the key as a bool (see GetBool)
+func (g *XDatasetGetter) Bool(key string) bool {
+	return GetterAs[bool](g, key)
+}
+
+// Int will read the value of the key as an int (see GetInt)
+func (g *XDatasetGetter) Int(key string) int {
+	return GetterAs[int](g, key)
+}
+
+// Float will read the value of the key as a float64 (see GetFloat)
+func (g *XDatasetGetter) Float(key string) float64 {
+	return GetterAs[float64](g, key)
+}
+
+// Time will read the value of the key as a time.Time (see GetTime)
+func (g *XDatasetGetter) Time(key string) time.Time {
+	return GetterAs[time.Time](g, key)
+}
+
+// Dataset will read the value of the key as a XDatasetDef (see GetDataset)
+func (g *XDatasetGetter) Dataset(key string) XDatasetDef {
+	return GetterAs[XDatasetDef](g, key)
+}
+
+// Collection will read the value of the key as a XDatasetCollectionDef (see GetCollection)
+func (g *XDatasetGetter) Collection(key string) XDatasetCollectionDef {
+	return GetterAs[XDatasetCollectionDef](g, key)
+}
+
+// Errors will return all the errors of the reads, in order
+func (g *XDatasetGetter) Errors() []error {
+	return g.errs
+}
+
+// Err will return all the errors of the reads joined into one error (usable with errors.Is), or nil if all the reads succeeded
+func (g *XDatasetGetter) Err() error {
+	return errors.Join(g.errs...)
+}
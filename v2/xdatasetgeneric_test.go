@@ -0,0 +1,123 @@
+package xcore
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestGetAs(t *testing.T) {
+	ds := &XDataset{
+		"name":  "Fred",
+		"port":  "5432",
+		"ratio": 0.5,
+		"debug": "true",
+		"since": "2020-01-02",
+		"tags":  []interface{}{"a", "b"},
+		"db":    &XDataset{"host": "localhost", "port": 3306},
+		"list":  &XDatasetCollection{&XDataset{"a": 1}},
+	}
+
+	if port, ok := GetAs[int](ds, "port"); !ok || port != 5432 {
+		t.Errorf("Error in GetAs[int]: %v %v", port, ok)
+	}
+	if port, ok := GetAs[uint16](ds, "db>port"); !ok || port != 3306 {
+		t.Errorf("Error in GetAs[uint16]: %v %v", port, ok)
+	}
+	if ratio, ok := GetAs[float32](ds, "ratio"); !ok || ratio != 0.5 {
+		t.Errorf("Error in GetAs[float32]: %v %v", ratio, ok)
+	}
+	if debug, ok := GetAs[bool](ds, "debug"); !ok || !debug {
+		t.Errorf("Error in GetAs[bool]: %v %v", debug, ok)
+	}
+	if since, ok := GetAs[time.Time](ds, "since"); !ok || since.Year() != 2020 {
+		t.Errorf("Error in GetAs[time.Time]: %v %v", since, ok)
+	}
+	if tags, ok := GetAs[[]string](ds, "tags"); !ok || len(tags) != 2 || tags[1] != "b" {
+		t.Errorf("Error in GetAs[[]string]: %v %v", tags, ok)
+	}
+	if db, ok := GetAs[XDatasetDef](ds, "db"); !ok || db != (*ds)["db"] {
+		t.Errorf("Error in GetAs[XDatasetDef]: %v %v", db, ok)
+	}
+	if list, ok := GetAs[XDatasetCollectionDef](ds, "list"); !ok || list.Count() != 1 {
+		t.Errorf("Error in GetAs[XDatasetCollectionDef]: %v %v", list, ok)
+	}
+	if _, ok := GetAs[int](ds, "name"); ok {
+		t.Error("Error: GetAs[int] of a string that is not a number should fail")
+	}
+	if _, ok := GetAs[*XDataset](ds, "name"); ok {
+		t.Error("Error: GetAs[*XDataset] of a string should fail")
+	}
+	if _, ok := GetAs[string](ds, "unknown"); ok {
+		t.Error("Error: GetAs of a missing key should fail")
+	}
+	// the integers out of the range of the type cannot be converted
+	ranges := &XDataset{"big": 300, "negative": -1, "small": 100}
+	if _, ok := GetAs[uint8](ranges, "big"); ok {
+		t.Error("Error: GetAs[uint8] of 300 should fail")
+	}
+	if _, ok := GetAs[uint](ranges, "negative"); ok {
+		t.Error("Error: GetAs[uint] of -1 should fail")
+	}
+	if _, ok := GetAs[int8](ranges, "big"); ok {
+		t.Error("Error: GetAs[int8] of 300 should fail")
+	}
+	if small, ok := GetAs[int8](ranges, "small"); !ok || small != 100 {
+		t.Errorf("Error in GetAs[int8]: %v %v", small, ok)
+	}
+	if negative, ok := GetAs[int16](ranges, "negative"); !ok || negative != -1 {
+		t.Errorf("Error in GetAs[int16]: %v %v", negative, ok)
+	}
+	func() {
+		defer func() {
+			if err, ok := recover().(error); !ok || !errors.Is(err, ErrDatasetConvert) {
+				t.Errorf("Error: MustGet[uint32] of -1 should panic with ErrDatasetConvert: %v", err)
+			}
+		}()
+		MustGet[uint32](ranges, "negative")
+	}()
+
+	if GetOr(ds, "port", 1) != 5432 || GetOr(ds, "unknown", 1) != 1 || GetOr(ds, "name", 1) != 1 || GetOr(ds, "db>host", "") != "localhost" {
+		t.Error("Error in GetOr")
+	}
+
+	// the thread safe dataset gives the same results
+	ts := NewXDatasetTS(ds.Clone())
+	if MustGet[int](ts, "db>port") != 3306 {
+		t.Error("Error in MustGet on XDatasetTS")
+	}
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("Error: MustGet of a missing key should panic")
+		} else if err, ok := r.(error); !ok || !errors.Is(err, ErrDatasetKey) {
+			t.Errorf("Error in the panic of MustGet: %v", r)
+		}
+	}()
+	MustGet[string](ds, "unknown")
+}
+
+func TestXDatasetGetter(t *testing.T) {
+	ds := &XDataset{"host": "localhost", "port": "5432", "debug": 1, "db": &XDataset{"name": "main"}}
+	g := NewXDatasetGetter(ds)
+	host := g.String("host")
+	port := g.Int("port")
+	debug := g.Bool("debug")
+	name := GetterAs[string](g, "db>name")
+	if g.Err() != nil || host != "localhost" || port != 5432 || !debug || name != "main" || g.Dataset("db") == nil {
+		t.Errorf("Error in the getter: %v %v %v %v %v", g.Err(), host, port, debug, name)
+	}
+
+	timeout := g.Float("timeout")
+	wrong := g.Int("host")
+	g.Collection("db")
+	if timeout != 0 || wrong != 0 || len(g.Errors()) != 3 {
+		t.Errorf("Error in the errors of the getter: %v", g.Errors())
+	}
+	err := g.Err()
+	if !errors.Is(err, ErrDatasetKey) || !errors.Is(err, ErrDatasetConvert) {
+		t.Errorf("Error in the joined error: %v", err)
+	}
+	if err.Error() != "Error: the key does not exist into the dataset: timeout\nError: the value cannot be converted to the asked type: host (string to int)\nError: the value cannot be converted to the asked type: db (*xcore.XDataset to xcore.XDatasetCollectionDef)" {
+		t.Errorf("Error in the message of the error: %v", err)
+	}
+}
@@ -0,0 +1,170 @@
+package xcore
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const testJSONDocument = `{
+  "clientname": "Fred",
+  "age": 42,
+  "salary": 3568.65,
+  "active": true,
+  "nothing": null,
+  "tags": ["a", "b", "c"],
+  "scores": [1, 2, 3],
+  "ratios": [1.5, 2, 3.25],
+  "flags": [true, false],
+  "mixed": ["a", 1, true],
+  "empty": [],
+  "hobbies": [
+    {"name": "Football", "sport": "yes"},
+    {"name": "Videogames", "sport": "no", "platforms": [{"name": "PC"}, {"name": "Switch"}]}
+  ],
+  "metadata": {
+    "preferred-color": "blue",
+    "address": {"city": "Mexico", "zip": "01000"}
+  }
+}`
+
+// sameJSON will compare 2 JSON codes by their decoded values
+func sameJSON(t *testing.T, a []byte, b []byte) bool {
+	var va, vb interface{}
+	if err := json.Unmarshal(a, &va); err != nil {
+		t.Error(err)
+		return false
+	}
+	if err := json.Unmarshal(b, &vb); err != nil {
+		t.Error(err)
+		return false
+	}
+	return reflect.DeepEqual(va, vb)
+}
+
+func TestXDataset_UnmarshalJSON(t *testing.T) {
+	ds := &XDataset{}
+	err := json.Unmarshal([]byte(testJSONDocument), ds)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	types := map[string]interface{}{
+		"clientname":                 "",
+		"age":                        0,
+		"salary":                     0.0,
+		"active":                     false,
+		"tags":                       []string{},
+		"scores":                     []int{},
+		"ratios":                     []float64{},
+		"flags":                      []bool{},
+		"mixed":                      []interface{}{},
+		"empty":                      &XDatasetCollection{},
+		"hobbies":                    &XDatasetCollection{},
+		"hobbies>1":                  &XDataset{},
+		"hobbies>1>platforms":        &XDatasetCollection{},
+		"hobbies>1>platforms>0>name": "",
+		"metadata":                   &XDataset{},
+		"metadata>address":           &XDataset{},
+		"metadata>address>zip":       "",
+	}
+	for key, expected := range types {
+		val, ok := ds.Get(key)
+		if !ok || reflect.TypeOf(val) != reflect.TypeOf(expected) {
+			t.Errorf("Error in the type of %s: %T", key, val)
+		}
+	}
+	if val, ok := ds.Get("nothing"); !ok || val != nil {
+		t.Errorf("Error in the null value: %v", val)
+	}
+	if v, _ := ds.GetIntCollection("scores"); !reflect.DeepEqual(v, []int{1, 2, 3}) {
+		t.Errorf("Error in the int collection: %v", v)
+	}
+	if v, _ := ds.GetString("hobbies>1>platforms>1>name"); v != "Switch" {
+		t.Errorf("Error in the nested collection: %v", v)
+	}
+
+	err = json.Unmarshal([]byte(`["not", "an", "object"]`), ds)
+	if err == nil {
+		t.Error("An array should not be decoded into a XDataset")
+	}
+}
+
+func TestXDataset_JSONRoundTrip(t *testing.T) {
+	tmp, _ := time.Parse(time.RFC3339, "2020-01-01T12:00:00Z")
+
+	datasets := []XDatasetDef{&XDataset{}, NewXDatasetTS(&XDataset{}), &XDatasetTS{}}
+	for _, ds := range datasets {
+		err := json.Unmarshal([]byte(testJSONDocument), ds)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		code, err := json.Marshal(ds)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if !sameJSON(t, code, []byte(testJSONDocument)) {
+			t.Errorf("Error in the JSON round trip of %T: %s", ds, code)
+		}
+		// twice: the decoded types must encode the same
+		ds2 := &XDataset{}
+		if err := json.Unmarshal(code, ds2); err != nil {
+			t.Error(err)
+			return
+		}
+		code2, _ := json.Marshal(ds2)
+		if string(code2) != string(code) {
+			t.Errorf("Error in the second JSON round trip of %T: %s", ds, code2)
+		}
+	}
+
+	// Encoding of nested thread safe structures and times
+	ds := &XDataset{
+		"time": tmp,
+		"ts":   NewXDatasetTS(&XDataset{"a": 1}),
+		"col":  &XDatasetCollectionTS{data: []XDatasetDef{&XDataset{"b": 2}}},
+		"nil":  &XDatasetCollection{},
+	}
+	code, err := json.Marshal(ds)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if string(code) != `{"col":[{"b":2}],"nil":[],"time":"2020-01-01T12:00:00Z","ts":{"a":1}}` {
+		t.Errorf("Error encoding the nested structures: %s", code)
+	}
+}
+
+func TestXDatasetCollection_JSONRoundTrip(t *testing.T) {
+	code := []byte(`[{"name": "Football", "score": 1}, {"name": "Swimming", "tags": ["water"]}]`)
+
+	collections := []XDatasetCollectionDef{&XDatasetCollection{}, &XDatasetCollectionTS{}}
+	for _, dc := range collections {
+		err := json.Unmarshal(code, dc)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if dc.Count() != 2 {
+			t.Errorf("Error decoding the collection %T: %v", dc, dc)
+		}
+		if v, _ := dc.GetData("tags"); !reflect.DeepEqual(v, []string{"water"}) {
+			t.Errorf("Error decoding the collection %T: %v", dc, v)
+		}
+		result, err := json.Marshal(dc)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if !sameJSON(t, result, code) {
+			t.Errorf("Error in the JSON round trip of %T: %s", dc, result)
+		}
+		if err := json.Unmarshal([]byte(`[1, 2]`), dc); err == nil {
+			t.Errorf("An array of scalars should not be decoded into %T", dc)
+		}
+	}
+}
@@ -0,0 +1,342 @@
+package xcore
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// XDatasetKeyedCollection is an ordered collection of XDatasetDefs with a unique key for each entry (for instance the primary key of a database table).
+// The entries keep the insertion order and can be retrieved by index (Get) or by key (GetByKey).
+// The key of the entries added with Push and Unshift is the value of the KeyField of the dataset.
+// XDatasetKeyedCollection is NOT thread safe
+type XDatasetKeyedCollection struct {
+	// KeyField is the field of the datasets used as key by Push and Unshift
+	KeyField string
+	keys     []string
+	data     map[string]XDatasetDef
+	sequence int
+}
+
+// NewXDatasetKeyedCollection will create an empty keyed collection, using the field keyfield of the datasets as key
+func NewXDatasetKeyedCollection(keyfield string) *XDatasetKeyedCollection {
+	return &XDatasetKeyedCollection{KeyField: keyfield, keys: []string{}, data: map[string]XDatasetDef{}}
+}
+
+// keyOf will return the key of the dataset: the value of the KeyField, or a new sequential key if the dataset does not have it
+func (d *XDatasetKeyedCollection) keyOf(data XDatasetDef) string {
+	if d.KeyField != "" && data != nil {
+		if key, ok := data.GetString(d.KeyField); ok && key != "" {
+			return key
+		}
+	}
+	for {
+		d.sequence++
+		key := strconv.Itoa(d.sequence)
+		if _, ok := d.data[key]; !ok {
+			return key
+		}
+	}
+}
+
+// insert will add the dataset with the key at the beginning or at the end of the collection. If the key already exists, the dataset is replaced at its position
+func (d *XDatasetKeyedCollection) insert(key string, data XDatasetDef, first bool) {
+	if d.data == nil {
+		d.data = map[string]XDatasetDef{}
+	}
+	if _, ok := d.data[key]; !ok {
+		if first {
+			d.keys = append([]string{key}, d.keys...)
+		} else {
+			d.keys = append(d.keys, key)
+		}
+	}
+	d.data[key] = data
+}
+
+// String will transform the XDatasetKeyedCollection into a readable string for humans
+func (d *XDatasetKeyedCollection) String() string {
+	sdata := []string{}
+	for _, key := range d.keys {
+		sdata = append(sdata, key+":"+fmt.Sprint(d.data[key]))
+	}
+	return "XDatasetKeyedCollection[" + strings.Join(sdata, " ") + "]"
+}
+
+// GoString will transform the XDatasetKeyedCollection into a readable string for humans
+func (d *XDatasetKeyedCollection) GoString() string {
+	return d.String()
+}
+
+// MarshalJSON will encode the collection as a JSON array of objects, in order
+func (d *XDatasetKeyedCollection) MarshalJSON() ([]byte, error) {
+	list := make([]XDatasetDef, 0, len(d.keys))
+	for _, key := range d.keys {
+		list = append(list, d.data[key])
+	}
+	return json.Marshal(list)
+}
+
+// Unshift will adds a XDatasetDef at the beginning of the collection, with the value of its KeyField as key.
+// If an entry already has the same key, it is replaced at its position (the dataset is not moved to the beginning)
+func (d *XDatasetKeyedCollection) Unshift(data XDatasetDef) {
+	d.insert(d.keyOf(data), data, true)
+}
+
+// Shift will remove the element at the beginning of the collection. Returns nil if the collection is empty
+func (d *XDatasetKeyedCollection) Shift() XDatasetDef {
+	if len(d.keys) == 0 {
+		return nil
+	}
+	key := d.keys[0]
+	data := d.data[key]
+	d.keys = d.keys[1:]
+	delete(d.data, key)
+	return data
+}
+
+// Push will adds a XDatasetDef at the end of the collection, with the value of its KeyField as key.
+// If an entry already has the same key, it is replaced at its position (the dataset is not moved to the end)
+func (d *XDatasetKeyedCollection) Push(data XDatasetDef) {
+	d.insert(d.keyOf(data), data, false)
+}
+
+// Pop will remove the element at the end of the collection. Returns nil if the collection is empty
+func (d *XDatasetKeyedCollection) Pop() XDatasetDef {
+	if len(d.keys) == 0 {
+		return nil
+	}
+	key := d.keys[len(d.keys)-1]
+	data := d.data[key]
+	d.keys = d.keys[:len(d.keys)-1]
+	delete(d.data, key)
+	return data
+}
+
+// Count will return the quantity of elements into the collection
+func (d *XDatasetKeyedCollection) Count() int {
+	return len(d.keys)
+}
+
+// Get will retrieve an element by index from the collection
+func (d *XDatasetKeyedCollection) Get(index int) (XDatasetDef, bool) {
+	if index < 0 || index >= len(d.keys) {
+		return nil, false
+	}
+	return d.data[d.keys[index]], true
+}
+
+// SetByKey will set the element with the key. If the key already exists, the element is replaced at its position, if not it is added at the end of the collection
+func (d *XDatasetKeyedCollection) SetByKey(key string, data XDatasetDef) {
+	d.insert(key, data, false)
+}
+
+// GetByKey will retrieve an element by key from the collection
+func (d *XDatasetKeyedCollection) GetByKey(key string) (XDatasetDef, bool) {
+	data, ok := d.data[key]
+	return data, ok
+}
+
+// DelByKey will remove the element with the key from the collection
+func (d *XDatasetKeyedCollection) DelByKey(key string) {
+	if _, ok := d.data[key]; !ok {
+		return
+	}
+	delete(d.data, key)
+	for i, k := range d.keys {
+		if k == key {
+			d.keys = append(d.keys[:i], d.keys[i+1:]...)
+			break
+		}
+	}
+}
+
+// entryKey will return the key of the entry of a path: the entry itself if it is a key of the collection, or the key at the index if the entry is an index.
+// It is the same rule as the reads (see collectionEntry)
+func (d *XDatasetKeyedCollection) entryKey(entry string) (string, bool) {
+	if _, ok := d.data[entry]; ok {
+		return entry, true
+	}
+	if index, err := strconv.Atoi(entry); err == nil {
+		return d.KeyAt(index)
+	}
+	return "", false
+}
+
+// SetPath will set the data at the path "entry>a>b" of the collection. The entry is resolved as in the reads: the key, then the index.
+// A path with only the entry replaces the entry (the data must be a XDatasetDef); an entry that does not exist is added at the end of the collection with the entry as key
+func (d *XDatasetKeyedCollection) SetPath(path string, data interface{}, collections bool) error {
+	head, rest, sub := splitPath(path)
+	key, ok := d.entryKey(head)
+	if !ok {
+		key = head
+	}
+	if !sub {
+		ds, ok := data.(XDatasetDef)
+		if !ok {
+			return fmt.Errorf("Error: the value of type %T cannot be an entry of the collection", data)
+		}
+		d.insert(key, ds, false)
+		return nil
+	}
+	ds, ok := d.data[key]
+	if !ok || ds == nil {
+		ds = &XDataset{}
+		if err := ds.(*XDataset).SetPath(rest, data, collections); err != nil {
+			return err
+		}
+		d.insert(key, ds, false)
+		return nil
+	}
+	return setDatasetPath(ds, rest, data, collections)
+}
+
+// Del will delete the data at the path "entry>a>b" of the collection. The entry is resolved as in the reads: the key, then the index.
+// If the path is only the entry, the entry is removed from the collection
+func (d *XDatasetKeyedCollection) Del(path string) {
+	head, rest, sub := splitPath(path)
+	key, ok := d.entryKey(head)
+	if !ok {
+		return
+	}
+	if !sub {
+		d.DelByKey(key)
+		return
+	}
+	if ds := d.data[key]; ds != nil {
+		ds.Del(rest)
+	}
+}
+
+// KeyAt will return the key of the element at the index of the collection
+func (d *XDatasetKeyedCollection) KeyAt(index int) (string, bool) {
+	if index < 0 || index >= len(d.keys) {
+		return "", false
+	}
+	return d.keys[index], true
+}
+
+// Keys will return the keys of the collection, in order
+func (d *XDatasetKeyedCollection) Keys() []string {
+	return append([]string{}, d.keys...)
+}
+
+// GetData will retrieve the first available data identified by key from the collection ordered by index
+// The key may start with level selectors: "..>" for the parent level, "/>" for the root level and "@N>" for the level N of the collection.
+func (d *XDatasetKeyedCollection) GetData(key string) (interface{}, bool) {
+	if level, subkey, ok := scopeSelector(key, len(d.keys)); ok {
+		ds, ok := d.Get(level)
+		if !ok {
+			return nil, false
+		}
+		if subkey == "" {
+			return ds, true
+		}
+		if ds == nil {
+			return nil, false
+		}
+		return ds.Get(subkey)
+	}
+	for i := len(d.keys) - 1; i >= 0; i-- {
+		// the nil entries (SetByKey with nil) are skipped
+		if ds := d.data[d.keys[i]]; ds != nil {
+			if val, ok := ds.Get(key); ok {
+				return val, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// GetDataString will retrieve the first available data identified by key from the collection ordered by index and return it as a string
+func (d *XDatasetKeyedCollection) GetDataString(key string) (string, bool) {
+	if val, ok := d.GetData(key); ok {
+		return convertString(val)
+	}
+	return "", false
+}
+
+// GetDataBool will retrieve the first available data identified by key from the collection ordered by index and return it as a boolean
+func (d *XDatasetKeyedCollection) GetDataBool(key string) (bool, bool) {
+	if val, ok := d.GetData(key); ok {
+		return convertBool(val)
+	}
+	return false, false
+}
+
+// GetDataInt will retrieve the first available data identified by key from the collection ordered by index and return it as an integer
+func (d *XDatasetKeyedCollection) GetDataInt(key string) (int, bool) {
+	if val, ok := d.GetData(key); ok {
+		return convertInt(val)
+	}
+	return 0, false
+}
+
+// GetDataFloat will retrieve the first available data identified by key from the collection ordered by index and return it as a float
+func (d *XDatasetKeyedCollection) GetDataFloat(key string) (float64, bool) {
+	if val, ok := d.GetData(key); ok {
+		return convertFloat(val)
+	}
+	return 0, false
+}
+
+// GetDataTime will retrieve the first available data identified by key from the collection ordered by index and return it as a time
+func (d *XDatasetKeyedCollection) GetDataTime(key string) (time.Time, bool) {
+	if val, ok := d.GetData(key); ok {
+		return convertTime(val)
+	}
+	return time.Time{}, false
+}
+
+// GetCollection will retrieve a collection from the XDatasetKeyedCollection
+func (d *XDatasetKeyedCollection) GetCollection(key string) (XDatasetCollectionDef, bool) {
+	if val, ok := d.GetData(key); ok {
+		if dsc, ok2 := val.(XDatasetCollectionDef); ok2 {
+			return dsc, true
+		}
+	}
+	return nil, false
+}
+
+// Clone will make a full copy of the object into memory
+func (d *XDatasetKeyedCollection) Clone() XDatasetCollectionDef {
+	cloned := NewXDatasetKeyedCollection(d.KeyField)
+	cloned.sequence = d.sequence
+	for _, key := range d.keys {
+		var data XDatasetDef
+		if d.data[key] != nil {
+			data = d.data[key].Clone()
+		}
+		cloned.insert(key, data, false)
+	}
+	return cloned
+}
+
+// collectionEntry will retrieve the entry of the collection identified by a path entry: the key for the keyed collections (then the index if the key does not exist), the index for the others
+func collectionEntry(dsc XDatasetCollectionDef, entry string) (XDatasetDef, bool) {
+	if keyed, ok := dsc.(interface {
+		GetByKey(string) (XDatasetDef, bool)
+	}); ok {
+		if ds, ok := keyed.GetByKey(entry); ok {
+			return ds, true
+		}
+	}
+	index, err := strconv.Atoi(entry)
+	if err != nil {
+		return nil, false
+	}
+	ds, ok := dsc.Get(index)
+	return ds, ok && ds != nil
+}
+
+// collectionKey will return the key of the entry at the index for the keyed collections
+func collectionKey(dsc XDatasetCollectionDef, index int) (string, bool) {
+	if keyed, ok := dsc.(interface {
+		KeyAt(int) (string, bool)
+	}); ok {
+		return keyed.KeyAt(index)
+	}
+	return "", false
+}
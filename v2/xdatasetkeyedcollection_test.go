@@ -0,0 +1,169 @@
+package xcore
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+func getKeyedCollection() *XDatasetKeyedCollection {
+	dsc := NewXDatasetKeyedCollection("id")
+	dsc.Push(&XDataset{"id": "ABC", "name": "Alice"})
+	dsc.Push(&XDataset{"id": "XYZ", "name": "Bob"})
+	dsc.Unshift(&XDataset{"id": 17, "name": "Carol"})
+	return dsc
+}
+
+func TestXDatasetKeyedCollection(t *testing.T) {
+	dsc := getKeyedCollection()
+	if dsc.Count() != 3 || dsc.String() != "XDatasetKeyedCollection[17:xcore.XDataset{id:17 name:Carol} ABC:xcore.XDataset{id:ABC name:Alice} XYZ:xcore.XDataset{id:XYZ name:Bob}]" {
+		t.Errorf("Error building the keyed collection: %v", dsc)
+		return
+	}
+	if ds, ok := dsc.GetByKey("ABC"); !ok || ds.String() != "xcore.XDataset{id:ABC name:Alice}" {
+		t.Errorf("Error in GetByKey: %v", ds)
+	}
+	if key, ok := dsc.KeyAt(2); !ok || key != "XYZ" {
+		t.Errorf("Error in KeyAt: %v", key)
+	}
+
+	// same key: replaced at its position
+	dsc.Push(&XDataset{"id": "ABC", "name": "Alicia"})
+	if name, _ := dsc.GetDataString("@1>name"); dsc.Count() != 3 || name != "Alicia" {
+		t.Errorf("Error replacing an existing key: %v", dsc)
+	}
+	// no key: sequential key
+	dsc.Push(&XDataset{"name": "Dan"})
+	if key, _ := dsc.KeyAt(3); key != "1" {
+		t.Errorf("Error in the sequential key: %v", key)
+	}
+
+	dsc.DelByKey("ABC")
+	dsc.DelByKey("unknown")
+	if _, ok := dsc.GetByKey("ABC"); ok || dsc.Count() != 3 {
+		t.Errorf("Error in DelByKey: %v", dsc)
+	}
+	if keys := dsc.Keys(); len(keys) != 3 || keys[0] != "17" || keys[1] != "XYZ" || keys[2] != "1" {
+		t.Errorf("Error in Keys: %v", keys)
+	}
+	if name, _ := dsc.GetDataString("name"); name != "Dan" {
+		t.Errorf("Error in GetDataString: %v", name)
+	}
+
+	cloned := dsc.Clone().(*XDatasetKeyedCollection)
+	dsc.SetByKey("XYZ", &XDataset{"name": "Robert"})
+	if ds, _ := cloned.GetByKey("XYZ"); ds.String() != "xcore.XDataset{id:XYZ name:Bob}" {
+		t.Errorf("Error in Clone: %v", ds)
+	}
+
+	if ds := dsc.Shift(); ds == nil || dsc.Count() != 2 {
+		t.Errorf("Error in Shift: %v", ds)
+	}
+	if ds := dsc.Pop(); ds == nil || dsc.Count() != 1 {
+		t.Errorf("Error in Pop: %v", ds)
+	}
+	dsc.Pop()
+	if dsc.Shift() != nil || dsc.Pop() != nil {
+		t.Error("Shift and Pop on an empty keyed collection should return nil")
+	}
+}
+
+func TestXDatasetKeyedCollection_Path(t *testing.T) {
+	data := &XDataset{"clients": getKeyedCollection()}
+	if name, ok := data.GetString("clients>XYZ>name"); !ok || name != "Bob" {
+		t.Errorf("Error in the key path: %v", name)
+	}
+	if name, ok := data.GetString("clients>17>name"); !ok || name != "Carol" {
+		t.Errorf("Error in the numeric key path: %v", name)
+	}
+	if name, ok := data.GetString("clients>0>name"); !ok || name != "Carol" {
+		t.Errorf("Error in the index path: %v", name)
+	}
+	if v := data.Query("clients>ABC>name"); len(v) != 1 || v[0] != "Alice" {
+		t.Errorf("Error in the key query: %v", v)
+	}
+
+	js, err := json.Marshal(data)
+	if err != nil || string(js) != `{"clients":[{"id":17,"name":"Carol"},{"id":"ABC","name":"Alice"},{"id":"XYZ","name":"Bob"}]}` {
+		t.Errorf("Error in MarshalJSON: %s %v", js, err)
+	}
+}
+
+func TestXTemplate_KeyedCollection(t *testing.T) {
+	tmpl, _ := NewXTemplateFromString(`@@clients:client@@
+[[client]]{{.key}}={{name}} [[]]
+[[client.key.ABC]]*{{name}}* [[]]`)
+	result := tmpl.Execute(&XDataset{"clients": getKeyedCollection()})
+	if result != "17=Carol *Alice* XYZ=Bob \n" {
+		t.Errorf("Error in template keyed collection: %s", result)
+	}
+}
+
+func TestXDatasetKeyedCollection_PathWrites(t *testing.T) {
+	// numeric keys: the reads and the writes resolve the entry by key first, then by index
+	items := NewXDatasetKeyedCollection("id")
+	items.Push(&XDataset{"id": 2, "name": "two"})
+	items.Push(&XDataset{"id": 1, "name": "one"})
+	ds := &XDataset{"items": items}
+
+	ds.Set("items>1>name", "uno")
+	if name, _ := ds.GetString("items>1>name"); name != "uno" {
+		t.Errorf("Error: the write and the read of items>1 are not the same entry: %v", items)
+	}
+	if name, _ := ds.GetString("items>2>name"); name != "two" {
+		t.Errorf("Error: the entry 2 has been modified: %v", items)
+	}
+	ds.Set("items>0>name", "first") // not a key: the index
+	if name, _ := ds.GetString("items>2>name"); name != "first" {
+		t.Errorf("Error: the index 0 has not been used: %v", items)
+	}
+	ds.Set("items>9>name", "nine") // new key
+	if key, _ := items.KeyAt(2); key != "9" {
+		t.Errorf("Error: the new key has not been added: %v", items)
+	}
+	ds.Del("items>2")
+	if items.Count() != 2 || ds.Exists("items>2") {
+		t.Errorf("Error: the entry 2 has not been deleted: %v", items)
+	}
+
+	// Diff and Patch use the keys
+	other := items.Clone().(*XDatasetKeyedCollection)
+	other.SetByKey("1", &XDataset{"id": 1, "name": "one"})
+	other.DelByKey("9")
+	other.SetByKey("3", &XDataset{"id": 3, "name": "three"})
+	changes, err := Diff(ds, &XDataset{"items": other})
+	if err != nil || changes.String() != "~ items>1>name: uno => one\n- items>9: xcore.XDataset{name:nine}\n+ items>3: xcore.XDataset{id:3 name:three}" {
+		t.Errorf("Error in the diff of keyed collections: %v %v", changes, err)
+	}
+	if err := Patch(ds, changes); err != nil || items.String() != other.String() {
+		t.Errorf("Error in the patch of keyed collections: %v %v", items, err)
+	}
+
+	// nil entries
+	var empty XDatasetKeyedCollection
+	empty.Push(nil)
+	if empty.String() != "XDatasetKeyedCollection[1:<nil>]" || empty.Clone().Count() != 1 {
+		t.Errorf("Error with a nil entry: %s", empty.String())
+	}
+}
+
+func TestXDatasetKeyedCollection_NilAndDuplicates(t *testing.T) {
+	dsc := NewXDatasetKeyedCollection("id")
+	dsc.Push(&XDataset{"id": "A", "name": "Fred"})
+	dsc.SetByKey("B", nil)
+	if name, ok := dsc.GetDataString("name"); !ok || name != "Fred" {
+		t.Errorf("Error: GetData must skip the nil entries: %v %v", name, ok)
+	}
+	if _, ok := dsc.Clone().GetData("@1>name"); ok {
+		t.Error("Error: GetData into a nil entry should fail")
+	}
+	// a duplicate key replaces the entry at its position
+	dsc.Push(&XDataset{"id": "C"})
+	dsc.Push(&XDataset{"id": "A", "name": "John"})
+	if keys := fmt.Sprint(dsc.Keys()); keys != "[A B C]" || dsc.Count() != 3 {
+		t.Errorf("Error pushing a duplicate key: %s", keys)
+	}
+	if ds, _ := dsc.GetByKey("A"); ds == nil || fmt.Sprint(ds) != "xcore.XDataset{id:A name:John}" {
+		t.Errorf("Error: the duplicate key must replace the entry: %v", ds)
+	}
+}
@@ -0,0 +1,251 @@
+package xcore
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// XDatasetPageFunc is the data source of a XDatasetLazyCollection: it loads the page (0-based) of size entries.
+// A page with less than size entries (or none) is the last page. A page with more than size entries is an error
+type XDatasetPageFunc func(page int, size int) ([]XDatasetDef, error)
+
+// XDatasetLazyCollection is a read only collection backed by a data source that loads the entries by pages, on demand.
+// Only one page is kept in memory for Get (and two for Each, the current one and the next one for the look-ahead), so very big collections
+// (for instance a report of 100k rows from a database) can be used in a template without loading all the entries in memory.
+// Count is known only once all the pages have been read: it reads the pages until the last one the first time.
+// Push and Unshift do nothing, Shift and Pop return nil.
+// XDatasetLazyCollection is NOT thread safe
+type XDatasetLazyCollection struct {
+	fetch XDatasetPageFunc
+	size  int
+	page  int
+	data  []XDatasetDef
+	count int
+	err   error
+}
+
+// NewXDatasetLazyCollection will create a lazy collection reading the entries from the data source by pages of size entries (minimum 1)
+func NewXDatasetLazyCollection(fetch XDatasetPageFunc, size int) *XDatasetLazyCollection {
+	if size < 1 {
+		size = 1
+	}
+	return &XDatasetLazyCollection{fetch: fetch, size: size, page: -1, count: -1}
+}
+
+// load will read the page from the data source if it is not the page into memory. The previous page is released
+func (d *XDatasetLazyCollection) load(page int) bool {
+	if page == d.page {
+		return true
+	}
+	d.page = -1
+	d.data = nil
+	data, err := d.fetchPage(page)
+	if err != nil {
+		return false
+	}
+	d.page = page
+	d.data = data
+	if len(data) < d.size {
+		d.count = page*d.size + len(data)
+	}
+	return true
+}
+
+// fetchPage will read the page from the data source. Returns an error (kept for Err) if the data source fails or returns more than size entries
+func (d *XDatasetLazyCollection) fetchPage(page int) ([]XDatasetDef, error) {
+	data, err := d.fetch(page, d.size)
+	if err == nil && len(data) > d.size {
+		err = fmt.Errorf("Error: the page %d of the data source has %d entries, more than the size %d", page, len(data), d.size)
+	}
+	if err != nil {
+		d.err = err
+		return nil, err
+	}
+	return data, nil
+}
+
+// Err will return the last error of the data source, if any
+func (d *XDatasetLazyCollection) Err() error {
+	return d.err
+}
+
+// Release will free the page in memory. The next Get will read it again from the data source
+func (d *XDatasetLazyCollection) Release() {
+	d.page = -1
+	d.data = nil
+}
+
+// Each will call the function with each entry of the collection in order, reading the pages sequentially and releasing them after use.
+// last is true for the last entry of the collection, known with a look-ahead on the next page. Count is not needed.
+// Returns the error of the data source (or of a page with more than size entries) or the first error returned by the function
+func (d *XDatasetLazyCollection) Each(fn func(index int, ds XDatasetDef, last bool) error) error {
+	current, err := d.fetchPage(0)
+	if err != nil {
+		return err
+	}
+	index := 0
+	for page := 0; len(current) > 0; page++ {
+		var next []XDatasetDef
+		if len(current) == d.size {
+			if next, err = d.fetchPage(page + 1); err != nil {
+				return err
+			}
+		}
+		for i, ds := range current {
+			if err := fn(index, ds, i == len(current)-1 && len(next) == 0); err != nil {
+				return err
+			}
+			index++
+		}
+		current = next
+	}
+	d.count = index
+	return nil
+}
+
+// String will transform the XDatasetLazyCollection into a readable string for humans. The entries are not read
+func (d *XDatasetLazyCollection) String() string {
+	count := "?"
+	if d.count >= 0 {
+		count = strconv.Itoa(d.count)
+	}
+	return "XDatasetLazyCollection[size:" + strconv.Itoa(d.size) + " count:" + count + "]"
+}
+
+// GoString will transform the XDatasetLazyCollection into a readable string for humans
+func (d *XDatasetLazyCollection) GoString() string {
+	return d.String()
+}
+
+// Unshift does nothing, the lazy collection is read only
+func (d *XDatasetLazyCollection) Unshift(data XDatasetDef) {
+}
+
+// Shift returns nil, the lazy collection is read only
+func (d *XDatasetLazyCollection) Shift() XDatasetDef {
+	return nil
+}
+
+// Push does nothing, the lazy collection is read only
+func (d *XDatasetLazyCollection) Push(data XDatasetDef) {
+}
+
+// Pop returns nil, the lazy collection is read only
+func (d *XDatasetLazyCollection) Pop() XDatasetDef {
+	return nil
+}
+
+// Count will return the quantity of elements into the collection. The first call reads all the pages of the data source until the last one.
+// Returns 0 if the data source fails (see Err)
+func (d *XDatasetLazyCollection) Count() int {
+	for page := 0; d.count < 0; page++ {
+		if !d.load(page) {
+			return 0
+		}
+	}
+	return d.count
+}
+
+// Get will retrieve an element by index from the collection, loading its page from the data source if needed
+func (d *XDatasetLazyCollection) Get(index int) (XDatasetDef, bool) {
+	if index < 0 || (d.count >= 0 && index >= d.count) {
+		return nil, false
+	}
+	if !d.load(index / d.size) {
+		return nil, false
+	}
+	pos := index % d.size
+	if pos >= len(d.data) {
+		return nil, false
+	}
+	return d.data[pos], true
+}
+
+// GetData will retrieve the first available data identified by key from the collection.
+// Unlike the other collections, the entries are read in order from the first one, up to the first entry with the key, so the collection is not counted.
+// The key may start with level selectors: "/>" for the root level and "@N>" for the level N of the collection, and "..>" for the parent level (that needs Count)
+func (d *XDatasetLazyCollection) GetData(key string) (interface{}, bool) {
+	count := d.count
+	if strings.HasPrefix(key, "..") {
+		count = d.Count()
+	}
+	if level, subkey, ok := scopeSelector(key, count); ok {
+		ds, ok := d.Get(level)
+		if !ok {
+			return nil, false
+		}
+		if subkey == "" {
+			return ds, true
+		}
+		return ds.Get(subkey)
+	}
+	for i := 0; ; i++ {
+		ds, ok := d.Get(i)
+		if !ok {
+			return nil, false
+		}
+		if ds == nil {
+			continue
+		}
+		if val, ok := ds.Get(key); ok {
+			return val, true
+		}
+	}
+}
+
+// GetDataString will retrieve the first available data identified by key from the collection ordered by index and return it as a string
+func (d *XDatasetLazyCollection) GetDataString(key string) (string, bool) {
+	if val, ok := d.GetData(key); ok {
+		return convertString(val)
+	}
+	return "", false
+}
+
+// GetDataBool will retrieve the first available data identified by key from the collection ordered by index and return it as a boolean
+func (d *XDatasetLazyCollection) GetDataBool(key string) (bool, bool) {
+	if val, ok := d.GetData(key); ok {
+		return convertBool(val)
+	}
+	return false, false
+}
+
+// GetDataInt will retrieve the first available data identified by key from the collection ordered by index and return it as an integer
+func (d *XDatasetLazyCollection) GetDataInt(key string) (int, bool) {
+	if val, ok := d.GetData(key); ok {
+		return convertInt(val)
+	}
+	return 0, false
+}
+
+// GetDataFloat will retrieve the first available data identified by key from the collection ordered by index and return it as a float
+func (d *XDatasetLazyCollection) GetDataFloat(key string) (float64, bool) {
+	if val, ok := d.GetData(key); ok {
+		return convertFloat(val)
+	}
+	return 0, false
+}
+
+// GetDataTime will retrieve the first available data identified by key from the collection ordered by index and return it as a time
+func (d *XDatasetLazyCollection) GetDataTime(key string) (time.Time, bool) {
+	if val, ok := d.GetData(key); ok {
+		return convertTime(val)
+	}
+	return time.Time{}, false
+}
+
+// GetCollection will retrieve a collection from the XDatasetLazyCollection
+func (d *XDatasetLazyCollection) GetCollection(key string) (XDatasetCollectionDef, bool) {
+	if val, ok := d.GetData(key); ok {
+		if dsc, ok2 := val.(XDatasetCollectionDef); ok2 {
+			return dsc, true
+		}
+	}
+	return nil, false
+}
+
+// Clone will build a new lazy collection on the same data source. The entries are not copied, they will be read again from the data source
+func (d *XDatasetLazyCollection) Clone() XDatasetCollectionDef {
+	return NewXDatasetLazyCollection(d.fetch, d.size)
+}
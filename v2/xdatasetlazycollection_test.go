@@ -0,0 +1,108 @@
+package xcore
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+)
+
+// getPageFunc builds a data source of total entries {"id": i}, counting the pages read
+func getPageFunc(total int, reads *int) XDatasetPageFunc {
+	return func(page int, size int) ([]XDatasetDef, error) {
+		*reads++
+		data := []XDatasetDef{}
+		for i := page * size; i < total && i < (page+1)*size; i++ {
+			data = append(data, &XDataset{"id": i})
+		}
+		return data, nil
+	}
+}
+
+func TestXDatasetLazyCollection(t *testing.T) {
+	reads := 0
+	dsc := NewXDatasetLazyCollection(getPageFunc(25, &reads), 10)
+	if ds, ok := dsc.Get(12); !ok || ds.String() != "xcore.XDataset{id:12}" || reads != 1 {
+		t.Errorf("Error in Get: %v %d", ds, reads)
+		return
+	}
+	dsc.Get(15)
+	if reads != 1 || dsc.String() != "XDatasetLazyCollection[size:10 count:?]" {
+		t.Errorf("Error in Get on the page in memory: %d %v", reads, dsc)
+	}
+	if ds, ok := dsc.Get(24); !ok || ds.String() != "xcore.XDataset{id:24}" {
+		t.Errorf("Error in Get on the last page: %v", ds)
+	}
+	if dsc.Count() != 25 || dsc.String() != "XDatasetLazyCollection[size:10 count:25]" {
+		t.Errorf("Error in Count: %d", dsc.Count())
+	}
+	if _, ok := dsc.Get(25); ok {
+		t.Error("Error in Get after the end of the collection")
+	}
+	if id, ok := dsc.GetDataInt("id"); !ok || id != 0 {
+		t.Errorf("Error in GetDataInt: %d", id)
+	}
+	// GetData reads the entries from the first one, without counting the collection
+	reads = 0
+	if id, ok := NewXDatasetLazyCollection(getPageFunc(1000, &reads), 10).GetDataInt("id"); !ok || id != 0 || reads != 1 {
+		t.Errorf("Error: GetData must not read all the pages: %d %d", id, reads)
+	}
+	if id, ok := NewXDatasetLazyCollection(getPageFunc(1000, &reads), 10).GetDataInt("@15>id"); !ok || id != 15 {
+		t.Errorf("Error in GetData with a level: %d", id)
+	}
+
+	reads = 0
+	ids := ""
+	err := NewXDatasetLazyCollection(getPageFunc(20, &reads), 10).Each(func(i int, ds XDatasetDef, last bool) error {
+		id, _ := ds.GetInt("id")
+		ids += strconv.Itoa(id)
+		if last {
+			ids += "."
+		}
+		return nil
+	})
+	if err != nil || ids != "012345678910111213141516171819." || reads != 3 {
+		t.Errorf("Error in Each: %s %d %v", ids, reads, err)
+	}
+
+	failure := errors.New("Error: connection lost")
+	failing := NewXDatasetLazyCollection(func(page int, size int) ([]XDatasetDef, error) {
+		if page > 0 {
+			return nil, failure
+		}
+		return []XDatasetDef{&XDataset{}, &XDataset{}}, nil
+	}, 2)
+	if err := failing.Each(func(int, XDatasetDef, bool) error { return nil }); err != failure {
+		t.Errorf("Error in Each with a failing data source: %v", err)
+	}
+	if _, ok := failing.Get(3); ok || failing.Err() != failure {
+		t.Errorf("Error in Get with a failing data source: %v", failing.Err())
+	}
+
+	// a page bigger than the size is an error, the entries are not lost silently
+	oversized := NewXDatasetLazyCollection(func(page int, size int) ([]XDatasetDef, error) {
+		return make([]XDatasetDef, size+1), nil
+	}, 10)
+	if err := oversized.Each(func(int, XDatasetDef, bool) error { return nil }); err == nil {
+		t.Error("Error: Each with a page bigger than the size should fail")
+	}
+	if _, ok := oversized.Get(0); ok || oversized.Err() == nil {
+		t.Error("Error: Get with a page bigger than the size should fail")
+	}
+}
+
+func TestXTemplate_LazyCollection(t *testing.T) {
+	tmpl, _ := NewXTemplateFromString(`@@rows:row@@
+[[row]]{{id}},[[]]
+[[row.first]]({{id}},[[]]
+[[row.last]]{{id}})[[]]
+[[row.none]]empty[[]]`)
+	reads := 0
+	result := tmpl.Execute(&XDataset{"rows": NewXDatasetLazyCollection(getPageFunc(7, &reads), 3)})
+	if result != "(0,1,2,3,4,5,6)\n" || reads != 3 {
+		t.Errorf("Error in template lazy collection: %s %d", result, reads)
+	}
+	result = tmpl.Execute(&XDataset{"rows": NewXDatasetLazyCollection(getPageFunc(0, &reads), 3)})
+	if result != "empty\n" {
+		t.Errorf("Error in template empty lazy collection: %s", result)
+	}
+}
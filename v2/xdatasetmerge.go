@@ -0,0 +1,325 @@
+package xcore
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// The strategies to merge the collections
+const (
+	// MergeReplace replaces the collection of the target by a copy of the collection of the other dataset
+	MergeReplace = iota
+	// MergeAppend adds a copy of the entries of the collection of the other dataset at the end of the collection of the target
+	MergeAppend
+	// MergeByKey merges the entries of the collections with the same value of the key field, and adds the other entries at the end
+	MergeByKey
+)
+
+// The operations of the changes, same as the RFC 6902 JSON Patch operations
+const (
+	ChangeAdd     = "add"
+	ChangeRemove  = "remove"
+	ChangeReplace = "replace"
+)
+
+// XDatasetMergeStrategy is the way to merge the collections of two datasets
+type XDatasetMergeStrategy struct {
+	// Collections is the strategy for the collections: MergeReplace (default), MergeAppend or MergeByKey
+	Collections int
+	// Key is the key field of the entries of the collections for MergeByKey
+	Key string
+}
+
+// XDatasetChange is a change of the value at the path "a>b>c" of a dataset.
+// Old is the value before the change (for replace and remove), Value is the value after the change (for add and replace)
+type XDatasetChange struct {
+	Op    string
+	Path  string
+	Old   interface{}
+	Value interface{}
+}
+
+// XDatasetChanges is the list of changes between two datasets, built by Diff and applied by Patch
+type XDatasetChanges []XDatasetChange
+
+// String will dump the list of changes, one by line
+func (c XDatasetChanges) String() string {
+	sdata := []string{}
+	for _, change := range c {
+		switch change.Op {
+		case ChangeAdd:
+			sdata = append(sdata, fmt.Sprintf("+ %s: %v", change.Path, change.Value))
+		case ChangeRemove:
+			sdata = append(sdata, fmt.Sprintf("- %s: %v", change.Path, change.Old))
+		default:
+			sdata = append(sdata, fmt.Sprintf("~ %s: %v => %v", change.Path, change.Old, change.Value))
+		}
+	}
+	return strings.Join(sdata, "\n")
+}
+
+// JSONPatch will build the RFC 6902 JSON Patch document of the list of changes
+func (c XDatasetChanges) JSONPatch() ([]byte, error) {
+	operations := []map[string]interface{}{}
+	for _, change := range c {
+		operation := map[string]interface{}{"op": change.Op, "path": jsonPointer(change.Path)}
+		if change.Op != ChangeRemove {
+			operation["value"] = change.Value
+		}
+		operations = append(operations, operation)
+	}
+	return json.Marshal(operations)
+}
+
+// jsonPointer will convert the path "a>b>c" to the RFC 6901 JSON Pointer "/a/b/c"
+func jsonPointer(path string) string {
+	replacer := strings.NewReplacer("~", "~0", "/", "~1")
+	pointer := ""
+	for _, entry := range strings.Split(path, ">") {
+		pointer += "/" + replacer.Replace(entry)
+	}
+	return pointer
+}
+
+// Merge will merge deeply the other dataset into the XDataset: the nested datasets are merged, the collections are merged with the strategy,
+// and the other values replace the values of the XDataset. The values of the other dataset are cloned.
+// If strategy is nil, the collections are replaced.
+func (d *XDataset) Merge(other XDatasetDef, strategy *XDatasetMergeStrategy) error {
+	return mergeDataset(d, other, strategy)
+}
+
+// Merge will merge deeply the other dataset into the XDatasetTS (see XDataset.Merge)
+func (ds *XDatasetTS) Merge(other XDatasetDef, strategy *XDatasetMergeStrategy) error {
+	ds.mutex.Lock()
+	defer ds.mutex.Unlock()
+	err := mergeDataset(ds.data, other, strategy)
+	guardNested(ds.data)
+	return err
+}
+
+// mergeDataset will merge the other dataset into the target dataset
+func mergeDataset(target XDatasetDef, other XDatasetDef, strategy *XDatasetMergeStrategy) error {
+	if strategy == nil {
+		strategy = &XDatasetMergeStrategy{}
+	}
+	keys, ok := datasetKeys(other)
+	if !ok {
+		return fmt.Errorf("Error: the dataset %T cannot list its keys to be merged", other)
+	}
+	for _, key := range keys {
+		value, _ := datasetKey(other, key)
+		current, exists := datasetKey(target, key)
+		if exists {
+			if ds, ok := current.(XDatasetDef); ok {
+				if ods, ok := value.(XDatasetDef); ok {
+					if err := mergeDataset(ds, ods, strategy); err != nil {
+						return err
+					}
+					continue
+				}
+			}
+			if dsc, ok := current.(XDatasetCollectionDef); ok {
+				if odsc, ok := value.(XDatasetCollectionDef); ok && strategy.Collections != MergeReplace {
+					if err := mergeCollection(dsc, odsc, strategy); err != nil {
+						return err
+					}
+					continue
+				}
+			}
+		}
+		setDatasetKey(target, key, cloneValue(value))
+	}
+	return nil
+}
+
+// mergeCollection will merge the other collection into the target collection with the MergeAppend or MergeByKey strategy
+func mergeCollection(target XDatasetCollectionDef, other XDatasetCollectionDef, strategy *XDatasetMergeStrategy) error {
+	if strategy.Collections == MergeByKey && strategy.Key == "" {
+		return errors.New("Error: the key field of the MergeByKey strategy is empty")
+	}
+	count := target.Count()
+	for i := 0; i < other.Count(); i++ {
+		ods, _ := other.Get(i)
+		if strategy.Collections == MergeByKey {
+			if ds, ok := findByKey(target, count, strategy.Key, ods); ok {
+				if err := mergeDataset(ds, ods, strategy); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		target.Push(ods.Clone())
+	}
+	return nil
+}
+
+// findByKey will search into the count first entries of the collection the entry with the same value of the key field than ds
+func findByKey(dsc XDatasetCollectionDef, count int, key string, ds XDatasetDef) (XDatasetDef, bool) {
+	value, ok := ds.Get(key)
+	if !ok {
+		return nil, false
+	}
+	for i := 0; i < count; i++ {
+		entry, _ := dsc.Get(i)
+		if v, ok := entry.Get(key); ok && reflect.DeepEqual(v, value) {
+			return entry, true
+		}
+	}
+	return nil, false
+}
+
+// cloneValue will clone the value if it is a dataset or a collection
+func cloneValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case XDatasetDef:
+		return v.Clone()
+	case XDatasetCollectionDef:
+		return v.Clone()
+	}
+	return value
+}
+
+// Diff will build the list of changes to apply to the dataset a to get the dataset b.
+// The nested datasets are compared key by key and the collections entry by entry. The datasets must be able to list their keys (XDataset, XDatasetTS...).
+func Diff(a XDatasetDef, b XDatasetDef) (XDatasetChanges, error) {
+	changes := XDatasetChanges{}
+	if err := diffDataset(&changes, "", a, b); err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// diffDataset will add to the changes the differences between the datasets a and b at the path prefix
+func diffDataset(changes *XDatasetChanges, prefix string, a XDatasetDef, b XDatasetDef) error {
+	akeys, ok1 := datasetKeys(a)
+	bkeys, ok2 := datasetKeys(b)
+	if !ok1 || !ok2 {
+		return errors.New("Error: the datasets cannot list their keys to be compared")
+	}
+	for _, key := range akeys {
+		if _, ok := datasetKey(b, key); !ok {
+			old, _ := datasetKey(a, key)
+			*changes = append(*changes, XDatasetChange{Op: ChangeRemove, Path: prefix + key, Old: old})
+		}
+	}
+	for _, key := range bkeys {
+		bvalue, _ := datasetKey(b, key)
+		avalue, ok := datasetKey(a, key)
+		if !ok {
+			*changes = append(*changes, XDatasetChange{Op: ChangeAdd, Path: prefix + key, Value: bvalue})
+			continue
+		}
+		if err := diffValue(changes, prefix+key, avalue, bvalue); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// diffValue will add to the changes the differences between the values a and b at the path
+func diffValue(changes *XDatasetChanges, path string, a interface{}, b interface{}) error {
+	if ads, ok := a.(XDatasetDef); ok {
+		if bds, ok := b.(XDatasetDef); ok {
+			return diffDataset(changes, path+">", ads, bds)
+		}
+	}
+	if adsc, ok := a.(XDatasetCollectionDef); ok {
+		if bdsc, ok := b.(XDatasetCollectionDef); ok {
+			if akeyed, ok := adsc.(keyedCollection); ok {
+				if bkeyed, ok := bdsc.(keyedCollection); ok {
+					return diffKeyed(changes, path, akeyed, bkeyed)
+				}
+			}
+			common := adsc.Count()
+			if bdsc.Count() < common {
+				common = bdsc.Count()
+			}
+			for i := 0; i < common; i++ {
+				ads, _ := adsc.Get(i)
+				bds, _ := bdsc.Get(i)
+				if err := diffValue(changes, path+">"+strconv.Itoa(i), ads, bds); err != nil {
+					return err
+				}
+			}
+			// the entries are removed from the last one so the indexes stay valid
+			for i := adsc.Count() - 1; i >= common; i-- {
+				ads, _ := adsc.Get(i)
+				*changes = append(*changes, XDatasetChange{Op: ChangeRemove, Path: path + ">" + strconv.Itoa(i), Old: ads})
+			}
+			for i := common; i < bdsc.Count(); i++ {
+				bds, _ := bdsc.Get(i)
+				*changes = append(*changes, XDatasetChange{Op: ChangeAdd, Path: path + ">" + strconv.Itoa(i), Value: bds})
+			}
+			return nil
+		}
+	}
+	if !reflect.DeepEqual(a, b) {
+		*changes = append(*changes, XDatasetChange{Op: ChangeReplace, Path: path, Old: a, Value: b})
+	}
+	return nil
+}
+
+// keyedCollection is a collection with a key for each entry, as XDatasetKeyedCollection
+type keyedCollection interface {
+	Keys() []string
+	GetByKey(string) (XDatasetDef, bool)
+}
+
+// diffKeyed will add to the changes the differences between two keyed collections, entry by key: the paths use the keys, as the reads and writes of the keyed collections
+func diffKeyed(changes *XDatasetChanges, path string, a keyedCollection, b keyedCollection) error {
+	for _, key := range a.Keys() {
+		ads, _ := a.GetByKey(key)
+		bds, ok := b.GetByKey(key)
+		if !ok {
+			*changes = append(*changes, XDatasetChange{Op: ChangeRemove, Path: path + ">" + key, Old: ads})
+			continue
+		}
+		if err := diffValue(changes, path+">"+key, ads, bds); err != nil {
+			return err
+		}
+	}
+	for _, key := range b.Keys() {
+		if _, ok := a.GetByKey(key); !ok {
+			bds, _ := b.GetByKey(key)
+			*changes = append(*changes, XDatasetChange{Op: ChangeAdd, Path: path + ">" + key, Value: bds})
+		}
+	}
+	return nil
+}
+
+// Patch will apply the list of changes to the dataset, in order. The values are cloned.
+// Returns an error if a path to remove or replace does not exist, or if a path cannot be set: then no change is applied
+func Patch(ds XDatasetDef, changes XDatasetChanges) error {
+	// the changes are applied to a copy first, so the dataset is not left half patched if one of them fails
+	if err := applyPatch(ds.Clone(), changes); err != nil {
+		return err
+	}
+	return applyPatch(ds, changes)
+}
+
+// applyPatch will apply the list of changes to the dataset, in order, up to the first error
+func applyPatch(ds XDatasetDef, changes XDatasetChanges) error {
+	for _, change := range changes {
+		switch change.Op {
+		case ChangeAdd, ChangeReplace:
+			if _, ok := ds.Get(change.Path); !ok && change.Op == ChangeReplace {
+				return errors.New("Error: the path to replace does not exist: " + change.Path)
+			}
+			if err := setDatasetPath(ds, change.Path, cloneValue(change.Value), true); err != nil {
+				return err
+			}
+		case ChangeRemove:
+			if _, ok := ds.Get(change.Path); !ok {
+				return errors.New("Error: the path to remove does not exist: " + change.Path)
+			}
+			ds.Del(change.Path)
+		default:
+			return errors.New("Error: unknown operation of change: " + change.Op)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package xcore
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestXDataset_Merge(t *testing.T) {
+	defaults := &XDataset{
+		"title": "My site",
+		"db":    &XDataset{"host": "localhost", "port": 3306},
+		"users": &XDatasetCollection{
+			&XDataset{"id": 1, "name": "Fred"},
+			&XDataset{"id": 2, "name": "Juan"},
+		},
+	}
+	site := &XDataset{
+		"db": &XDataset{"host": "db.example.com"},
+		"users": &XDatasetCollection{
+			&XDataset{"id": 2, "name": "Juan Carlos"},
+			&XDataset{"id": 3, "name": "Ana"},
+		},
+	}
+
+	ds := defaults.Clone().(*XDataset)
+	if err := ds.Merge(site, nil); err != nil {
+		t.Error(err)
+		return
+	}
+	if host, _ := ds.GetString("db>host"); host != "db.example.com" {
+		t.Errorf("Error merging the nested dataset: %v", ds)
+		return
+	}
+	if port, _ := ds.GetInt("db>port"); port != 3306 {
+		t.Errorf("Error merging the nested dataset: %v", ds)
+		return
+	}
+	if users, _ := ds.GetCollection("users"); users.Count() != 2 {
+		t.Errorf("Error replacing the collection: %v", ds)
+		return
+	}
+
+	ds = defaults.Clone().(*XDataset)
+	ds.Merge(site, &XDatasetMergeStrategy{Collections: MergeAppend})
+	if users, _ := ds.GetCollection("users"); users.Count() != 4 {
+		t.Errorf("Error appending the collection: %v", ds)
+		return
+	}
+
+	ds = defaults.Clone().(*XDataset)
+	ds.Merge(site, &XDatasetMergeStrategy{Collections: MergeByKey, Key: "id"})
+	users, _ := ds.GetCollection("users")
+	name, _ := ds.GetString("users>1>name")
+	if users.Count() != 3 || name != "Juan Carlos" {
+		t.Errorf("Error merging the collection by key: %v", ds)
+		return
+	}
+	if err := ds.Merge(site, &XDatasetMergeStrategy{Collections: MergeByKey}); err == nil {
+		t.Error("MergeByKey without key should fail")
+		return
+	}
+
+	// the values are cloned
+	ds.Set("db>host", "other")
+	if host, _ := site.GetString("db>host"); host != "db.example.com" {
+		t.Errorf("The merged values must be cloned: %v", site)
+		return
+	}
+
+	ts := NewXDatasetTS(&XDataset{"db": &XDataset{"port": 3306}})
+	ts.Merge(site, nil)
+	if host, _ := ts.GetString("db>host"); host != "db.example.com" {
+		t.Errorf("Error merging into XDatasetTS: %v", ts)
+		return
+	}
+}
+
+func TestDiffPatch(t *testing.T) {
+	a := &XDataset{
+		"name":    "Fred",
+		"age":     30,
+		"address": &XDataset{"city": "Mexico", "zip": "01000"},
+		"hobbies": &XDatasetCollection{
+			&XDataset{"name": "Chess"},
+			&XDataset{"name": "Tennis"},
+			&XDataset{"name": "Golf"},
+		},
+		"tags": []string{"a", "b"},
+	}
+	b := &XDataset{
+		"name":    "Fred",
+		"address": &XDataset{"city": "Paris", "zip": "01000", "country": "France"},
+		"hobbies": &XDatasetCollection{
+			&XDataset{"name": "Chess", "level": "high"},
+		},
+		"tags":  []string{"a", "c"},
+		"a/b~c": nil,
+	}
+	changes, err := Diff(a, b)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	str := changes.String()
+	if str != `- age: 30
++ a/b~c: <nil>
+~ address>city: Mexico => Paris
++ address>country: France
++ hobbies>0>level: high
+- hobbies>2: xcore.XDataset{name:Golf}
+- hobbies>1: xcore.XDataset{name:Tennis}
+~ tags: [a b] => [a c]` {
+		t.Errorf("Error building the diff: %s", str)
+		return
+	}
+
+	patch, err := changes.JSONPatch()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if string(patch) != `[{"op":"remove","path":"/age"},{"op":"add","path":"/a~1b~0c","value":null},{"op":"replace","path":"/address/city","value":"Paris"},{"op":"add","path":"/address/country","value":"France"},{"op":"add","path":"/hobbies/0/level","value":"high"},{"op":"remove","path":"/hobbies/2"},{"op":"remove","path":"/hobbies/1"},{"op":"replace","path":"/tags","value":["a","c"]}]` {
+		t.Errorf("Error building the JSON patch: %s", patch)
+		return
+	}
+
+	if err := Patch(a, changes); err != nil {
+		t.Error(err)
+		return
+	}
+	if fmt.Sprint(a) != fmt.Sprint(b) {
+		t.Errorf("Error applying the patch: %v", a)
+		return
+	}
+	changes, _ = Diff(a, b)
+	if len(changes) != 0 {
+		t.Errorf("There should be no more changes: %s", changes)
+		return
+	}
+
+	// grow a collection
+	changes, _ = Diff(&XDataset{"list": &XDatasetCollection{}}, &XDataset{"list": &XDatasetCollection{&XDataset{"v": 1}, &XDataset{"v": 2}}})
+	c := &XDataset{"list": &XDatasetCollection{}}
+	if err := Patch(c, changes); err != nil {
+		t.Error(err)
+		return
+	}
+	if v, _ := c.GetInt("list>1>v"); v != 2 {
+		t.Errorf("Error applying the patch: %v", c)
+		return
+	}
+
+	if err := Patch(c, XDatasetChanges{{Op: ChangeRemove, Path: "unknown"}}); err == nil {
+		t.Error("Removing an unknown path should fail")
+		return
+	}
+	if err := Patch(c, XDatasetChanges{{Op: ChangeReplace, Path: "unknown", Value: 1}}); err == nil {
+		t.Error("Replacing an unknown path should fail")
+		return
+	}
+	// a failing patch does not apply any change
+	before := fmt.Sprint(c)
+	if err := Patch(c, XDatasetChanges{{Op: ChangeAdd, Path: "name", Value: "x"}, {Op: ChangeRemove, Path: "list>0"}, {Op: ChangeRemove, Path: "unknown"}}); err == nil || fmt.Sprint(c) != before {
+		t.Errorf("Error: a failing patch must not modify the dataset: %v %v", err, c)
+		return
+	}
+}
@@ -0,0 +1,328 @@
+package xcore
+
+import (
+	"encoding/json"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// XDatasetListener is a function called with each change of a XDatasetObservable
+type XDatasetListener func(change XDatasetChange)
+
+// xdatasetSubscription is a listener registered into a XDatasetObservable
+type xdatasetSubscription struct {
+	id       int
+	listener XDatasetListener
+}
+
+// XDatasetObservable is a thread safe encapsulator of a XDatasetDef that notifies its changes to the subscribed listeners.
+// Each Set, SetPath and Del that modifies the dataset emits a XDatasetChange with the operation (ChangeAdd, ChangeReplace or ChangeRemove),
+// the path "a>b>c", the old value and the new value. A Set with the same value than the current one does not emit any change.
+//
+// The changes are delivered in the order they are applied to the dataset, to all the listeners in the order of subscription, one change at a time.
+// A listener may modify the dataset: its changes are delivered after the current one.
+// When a change is made while other changes are being delivered (by another goroutine or by a listener), it is delivered by the call in progress,
+// so Set may return before its change is delivered.
+// If a listener panics, the panic goes on to the caller of Set, SetPath or Del, and the changes still queued are delivered by the next call.
+// The changes made directly into the nested datasets (obtained with GetDataset) are not observed: use the paths "a>b>c" to modify them.
+type XDatasetObservable struct {
+	mutex     sync.RWMutex
+	data      XDatasetDef
+	queue     sync.Mutex
+	pending   []XDatasetChange
+	running   bool
+	listeners []xdatasetSubscription
+	lastid    int
+}
+
+// NewXDatasetObservable builds an observable encapsulator on a XDataset compatible structure. The structure must not be modified directly anymore
+func NewXDatasetObservable(maindata XDatasetDef) *XDatasetObservable {
+	if maindata == nil {
+		maindata = &XDataset{}
+	}
+	return &XDatasetObservable{data: maindata}
+}
+
+// Subscribe will register the listener to be called with all the next changes of the dataset.
+// Returns the function to unsubscribe the listener: once it returns, the listener is not called anymore (except for the call in progress, if any)
+func (o *XDatasetObservable) Subscribe(listener XDatasetListener) func() {
+	o.queue.Lock()
+	defer o.queue.Unlock()
+	o.lastid++
+	id := o.lastid
+	o.listeners = append(o.listeners, xdatasetSubscription{id: id, listener: listener})
+	return func() {
+		o.queue.Lock()
+		defer o.queue.Unlock()
+		for i, s := range o.listeners {
+			if s.id == id {
+				o.listeners = append(o.listeners[:i:i], o.listeners[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// SubscribeChan will send all the next changes of the dataset to the channel, in order.
+// The send blocks the delivery of the changes to all the listeners until the channel accepts it, so the channel should be buffered and always read.
+// Returns the function to unsubscribe the channel (the channel is not closed)
+func (o *XDatasetObservable) SubscribeChan(ch chan<- XDatasetChange) func() {
+	return o.Subscribe(func(change XDatasetChange) {
+		ch <- change
+	})
+}
+
+// enqueue will add the change to the queue of changes to deliver. It is called under the lock of the data, so the changes are queued in the order they are applied
+func (o *XDatasetObservable) enqueue(change XDatasetChange) {
+	o.queue.Lock()
+	o.pending = append(o.pending, change)
+	o.queue.Unlock()
+}
+
+// deliver will deliver the queued changes to the listeners, unless another call is already delivering them.
+// If a listener panics, the delivery stops and the panic goes on: the changes still queued are delivered by the next call
+func (o *XDatasetObservable) deliver() {
+	o.queue.Lock()
+	if o.running {
+		o.queue.Unlock()
+		return
+	}
+	o.running = true
+	for len(o.pending) > 0 {
+		change := o.pending[0]
+		o.pending = o.pending[1:]
+		// the listeners are searched again after each call, to skip the unsubscribed ones and ignore the new ones
+		maxid := o.lastid
+		for id := 0; ; {
+			s, ok := o.nextListener(id, maxid)
+			if !ok {
+				break
+			}
+			id = s.id
+			o.queue.Unlock()
+			o.notify(s.listener, change)
+			o.queue.Lock()
+		}
+	}
+	o.running = false
+	o.queue.Unlock()
+}
+
+// notify will call the listener with the change. It is called without the lock of the queue:
+// if the listener panics, the lock is taken again to end the delivery in progress, then the panic goes on
+func (o *XDatasetObservable) notify(listener XDatasetListener, change XDatasetChange) {
+	defer func() {
+		if r := recover(); r != nil {
+			o.queue.Lock()
+			o.running = false
+			o.queue.Unlock()
+			panic(r)
+		}
+	}()
+	listener(change)
+}
+
+// nextListener will return the first listener subscribed after the listener id, up to maxid
+func (o *XDatasetObservable) nextListener(id int, maxid int) (xdatasetSubscription, bool) {
+	for _, s := range o.listeners {
+		if s.id > id && s.id <= maxid {
+			return s, true
+		}
+	}
+	return xdatasetSubscription{}, false
+}
+
+// changeOf will build the change of the value at the path: ChangeAdd if there was no value, ChangeReplace if the value is different. Returns false if the value does not change
+func changeOf(path string, old interface{}, exists bool, value interface{}) (XDatasetChange, bool) {
+	if !exists {
+		return XDatasetChange{Op: ChangeAdd, Path: path, Value: value}, true
+	}
+	if reflect.DeepEqual(old, value) {
+		return XDatasetChange{}, false
+	}
+	return XDatasetChange{Op: ChangeReplace, Path: path, Old: old, Value: value}, true
+}
+
+// Set will set the value at the path key "a>b>c" of the dataset (creating the missing datasets) and notify the change.
+// As XDataset.Set, if the path cannot be followed the value is set at the literal key, and the change is notified with the literal key as path
+func (o *XDatasetObservable) Set(key string, data interface{}) {
+	o.set(key, data, false, true)
+}
+
+// SetPath will set the value at the path key "a>b>c" of the dataset, creating the missing intermediate entries (see XDataset.SetPath), and notify the change
+func (o *XDatasetObservable) SetPath(key string, data interface{}, collections bool) error {
+	return o.set(key, data, collections, false)
+}
+
+// set will set the value at the path key and notify the change. If literal is true and the path cannot be followed, the value is set at the literal key
+func (o *XDatasetObservable) set(key string, data interface{}, collections bool, literal bool) error {
+	o.mutex.Lock()
+	old, exists := o.data.Get(key)
+	change, changed := changeOf(key, old, exists, data)
+	var err error
+	if changed {
+		err = setDatasetPath(o.data, key, data, collections)
+		if err != nil && literal {
+			old, exists = datasetKey(o.data, key)
+			change, changed = changeOf(key, old, exists, data)
+			if changed {
+				setDatasetKey(o.data, key, data)
+			}
+			err = nil
+		}
+		if err == nil && changed {
+			o.enqueue(change)
+		}
+	}
+	o.mutex.Unlock()
+	o.deliver()
+	return err
+}
+
+// Del will delete the value at the path key "a>b>c" of the dataset and notify the change, if the value exists
+func (o *XDatasetObservable) Del(key string) {
+	o.mutex.Lock()
+	old, exists := o.data.Get(key)
+	if exists {
+		o.data.Del(key)
+		o.enqueue(XDatasetChange{Op: ChangeRemove, Path: key, Old: old})
+	}
+	o.mutex.Unlock()
+	o.deliver()
+}
+
+// MarshalJSON will encode the observed dataset into JSON
+func (o *XDatasetObservable) MarshalJSON() ([]byte, error) {
+	o.mutex.RLock()
+	defer o.mutex.RUnlock()
+	return json.Marshal(o.data)
+}
+
+// String will transform the observed dataset into a readable string for humans
+func (o *XDatasetObservable) String() string {
+	o.mutex.RLock()
+	defer o.mutex.RUnlock()
+	return o.data.String()
+}
+
+// GoString will transform the observed dataset into a readable string for humans
+func (o *XDatasetObservable) GoString() string {
+	o.mutex.RLock()
+	defer o.mutex.RUnlock()
+	return o.data.GoString()
+}
+
+// Get will read the value of the key variable. The key may be a path "a>b>c"
+func (o *XDatasetObservable) Get(key string) (interface{}, bool) {
+	o.mutex.RLock()
+	defer o.mutex.RUnlock()
+	return o.data.Get(key)
+}
+
+// GetDataset will read the value of the key variable as a XDatasetDef cast type
+func (o *XDatasetObservable) GetDataset(key string) (XDatasetDef, bool) {
+	o.mutex.RLock()
+	defer o.mutex.RUnlock()
+	return o.data.GetDataset(key)
+}
+
+// GetCollection will read the value of the key variable as a XDatasetCollection cast type
+func (o *XDatasetObservable) GetCollection(key string) (XDatasetCollectionDef, bool) {
+	o.mutex.RLock()
+	defer o.mutex.RUnlock()
+	return o.data.GetCollection(key)
+}
+
+// GetString will read the value of the key variable as a string cast type
+func (o *XDatasetObservable) GetString(key string) (string, bool) {
+	o.mutex.RLock()
+	defer o.mutex.RUnlock()
+	return o.data.GetString(key)
+}
+
+// GetBool will read the value of the key variable as a boolean cast type
+func (o *XDatasetObservable) GetBool(key string) (bool, bool) {
+	o.mutex.RLock()
+	defer o.mutex.RUnlock()
+	return o.data.GetBool(key)
+}
+
+// GetInt will read the value of the key variable as an integer cast type
+func (o *XDatasetObservable) GetInt(key string) (int, bool) {
+	o.mutex.RLock()
+	defer o.mutex.RUnlock()
+	return o.data.GetInt(key)
+}
+
+// GetFloat will read the value of the key variable as a float64 cast type
+func (o *XDatasetObservable) GetFloat(key string) (float64, bool) {
+	o.mutex.RLock()
+	defer o.mutex.RUnlock()
+	return o.data.GetFloat(key)
+}
+
+// GetTime will read the value of the key variable as a time cast type
+func (o *XDatasetObservable) GetTime(key string) (time.Time, bool) {
+	o.mutex.RLock()
+	defer o.mutex.RUnlock()
+	return o.data.GetTime(key)
+}
+
+// GetStringCollection will read the value of the key variable as a collection of strings cast type
+func (o *XDatasetObservable) GetStringCollection(key string) ([]string, bool) {
+	o.mutex.RLock()
+	defer o.mutex.RUnlock()
+	return o.data.GetStringCollection(key)
+}
+
+// GetBoolCollection will read the value of the key variable as a collection of bool cast type
+func (o *XDatasetObservable) GetBoolCollection(key string) ([]bool, bool) {
+	o.mutex.RLock()
+	defer o.mutex.RUnlock()
+	return o.data.GetBoolCollection(key)
+}
+
+// GetIntCollection will read the value of the key variable as a collection of int cast type
+func (o *XDatasetObservable) GetIntCollection(key string) ([]int, bool) {
+	o.mutex.RLock()
+	defer o.mutex.RUnlock()
+	return o.data.GetIntCollection(key)
+}
+
+// GetFloatCollection will read the value of the key variable as a collection of float cast type
+func (o *XDatasetObservable) GetFloatCollection(key string) ([]float64, bool) {
+	o.mutex.RLock()
+	defer o.mutex.RUnlock()
+	return o.data.GetFloatCollection(key)
+}
+
+// GetTimeCollection will read the value of the key variable as a collection of time cast type
+func (o *XDatasetObservable) GetTimeCollection(key string) ([]time.Time, bool) {
+	o.mutex.RLock()
+	defer o.mutex.RUnlock()
+	return o.data.GetTimeCollection(key)
+}
+
+// Exists will check if the key (or path "a>b>c") exists into the dataset
+func (o *XDatasetObservable) Exists(key string) bool {
+	o.mutex.RLock()
+	defer o.mutex.RUnlock()
+	_, ok := o.data.Get(key)
+	return ok
+}
+
+// Keys will return the sorted list of the keys of the observed dataset, if it is able to list them
+func (o *XDatasetObservable) Keys() []string {
+	o.mutex.RLock()
+	defer o.mutex.RUnlock()
+	keys, _ := datasetKeys(o.data)
+	return keys
+}
+
+// Clone will build a new XDatasetObservable with a copy of the dataset and without listeners
+func (o *XDatasetObservable) Clone() XDatasetDef {
+	o.mutex.RLock()
+	defer o.mutex.RUnlock()
+	return NewXDatasetObservable(o.data.Clone())
+}
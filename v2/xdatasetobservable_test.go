@@ -0,0 +1,134 @@
+package xcore
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestXDatasetObservable(t *testing.T) {
+	ds := NewXDatasetObservable(&XDataset{"name": "site", "db": &XDataset{"host": "localhost"}})
+	changes := XDatasetChanges{}
+	unsubscribe := ds.Subscribe(func(change XDatasetChange) {
+		changes = append(changes, change)
+	})
+	ch := make(chan XDatasetChange, 10)
+	ds.SubscribeChan(ch)
+
+	ds.Set("name", "site")
+	ds.Set("name", "portal")
+	ds.Set("db>host", "remote")
+	ds.Set("db>port", 5432)
+	ds.Set("cache>ttl", 60)
+	ds.Del("db>port")
+	ds.Del("unknown")
+	if err := ds.SetPath("name>sub", 1, false); err == nil {
+		t.Error("Error: SetPath into a string should fail")
+	}
+	if changes.String() != "~ name: site => portal\n~ db>host: localhost => remote\n+ db>port: 5432\n+ cache>ttl: 60\n- db>port: 5432" {
+		t.Errorf("Error in the changes: %v", changes)
+		return
+	}
+	if len(ch) != 5 {
+		t.Errorf("Error in the changes of the channel: %d", len(ch))
+	}
+	if ttl, _ := ds.GetInt("cache>ttl"); ttl != 60 || ds.Exists("db>port") {
+		t.Errorf("Error in the dataset: %v", ds)
+	}
+
+	unsubscribe()
+	ds.Set("name", "other")
+	if len(changes) != 5 || len(ch) != 6 {
+		t.Errorf("Error: the listener is called after unsubscribe: %d %d", len(changes), len(ch))
+	}
+
+	// a listener that modifies the dataset: its change is delivered after the current one to all the listeners
+	order := []string{}
+	ds.Subscribe(func(change XDatasetChange) {
+		order = append(order, "1:"+change.Path)
+		if change.Path == "a" {
+			ds.Set("b", 1)
+		}
+	})
+	ds.Subscribe(func(change XDatasetChange) {
+		order = append(order, "2:"+change.Path)
+	})
+	for len(ch) > 0 {
+		<-ch
+	}
+	ds.Set("a", 1)
+	if len(order) != 4 || order[0] != "1:a" || order[1] != "2:a" || order[2] != "1:b" || order[3] != "2:b" {
+		t.Errorf("Error in the order of the changes: %v", order)
+	}
+	if cloned := ds.Clone(); cloned.String() != ds.String() {
+		t.Errorf("Error in Clone: %v", cloned)
+	}
+
+	// Set falls back to the literal key as XDataset.Set, and notifies it
+	literal := NewXDatasetObservable(&XDataset{"name": "site"})
+	literals := XDatasetChanges{}
+	literal.Subscribe(func(change XDatasetChange) {
+		literals = append(literals, change)
+	})
+	literal.Set("name>sub", 1)
+	literal.Set("name>sub", 1)
+	literal.Set("name>sub", 2)
+	if value, _ := literal.Get("name>sub"); value != 2 || literals.String() != "+ name>sub: 1\n~ name>sub: 1 => 2" {
+		t.Errorf("Error in the literal key of Set: %v %v", value, literals)
+	}
+}
+
+func TestXDatasetObservable_Panic(t *testing.T) {
+	ds := NewXDatasetObservable(nil)
+	paths := []string{}
+	ds.Subscribe(func(change XDatasetChange) {
+		if change.Path == "bad" {
+			panic("listener failed")
+		}
+		paths = append(paths, change.Path)
+	})
+	func() {
+		defer func() {
+			if r := recover(); r != "listener failed" {
+				t.Errorf("Error: the panic of the listener must go on: %v", r)
+			}
+		}()
+		ds.Set("bad", 1)
+	}()
+	// the delivery is not stuck after the panic
+	ds.Set("good", 1)
+	if len(paths) != 1 || paths[0] != "good" {
+		t.Errorf("Error: the changes after a panic are not delivered: %v", paths)
+	}
+}
+
+func TestXDatasetObservable_Concurrent(t *testing.T) {
+	ds := NewXDatasetObservable(nil)
+	// the old value of each change must be the value of the previous change of the same path
+	last := map[string]interface{}{}
+	count := 0
+	ds.Subscribe(func(change XDatasetChange) {
+		if change.Old != last[change.Path] {
+			t.Errorf("Error: change out of order on %s: %v after %v", change.Path, change.Old, last[change.Path])
+		}
+		last[change.Path] = change.Value
+		count++
+	})
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 1; i <= 100; i++ {
+				ds.Set("workers>"+strconv.Itoa(w), i)
+				ds.Set("shared", w*1000+i*10000)
+				ds.Get("workers>" + strconv.Itoa(w))
+			}
+		}(w)
+	}
+	wg.Wait()
+	ds.Set("end", 1)
+	if count != 1601 {
+		t.Errorf("Error: lost changes: %d", count)
+	}
+}
@@ -0,0 +1,176 @@
+package xcore
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// splitPath will separate the first entry of the path key "a>b>c" from the rest of the path.
+// Returns false if the key has only one entry
+func splitPath(key string) (string, string, bool) {
+	pos := strings.Index(key, ">")
+	if pos < 0 {
+		return key, "", false
+	}
+	return key[:pos], key[pos+1:], true
+}
+
+// isPathIndex will check if the entry of a path is a numeric index of a collection
+func isPathIndex(entry string) bool {
+	_, err := strconv.Atoi(entry)
+	return err == nil
+}
+
+// setPathChild will set the data at the path rest into the child value of a dataset.
+// If the child does not exist (or is nil), a new XDataset is created (or a new XDatasetCollection if collections is true and the next entry is numeric).
+// Returns the child to store into the dataset if it has been created, or nil if the child has been modified in place
+func setPathChild(child interface{}, rest string, data interface{}, collections bool) (interface{}, error) {
+	switch c := child.(type) {
+	case XDatasetDef:
+		return nil, setDatasetPath(c, rest, data, collections)
+	case XDatasetCollectionDef:
+		return nil, setCollectionPath(c, rest, data, collections)
+	case nil:
+		head, _, _ := splitPath(rest)
+		if collections && isPathIndex(head) {
+			dsc := &XDatasetCollection{}
+			if err := dsc.SetPath(rest, data, collections); err != nil {
+				return nil, err
+			}
+			return dsc, nil
+		}
+		ds := &XDataset{}
+		if err := ds.SetPath(rest, data, collections); err != nil {
+			return nil, err
+		}
+		return ds, nil
+	}
+	return nil, fmt.Errorf("Error: the value of type %T is not a dataset or a collection", child)
+}
+
+// setDatasetPath will set the data at the path key of any XDatasetDef, with SetPath if the dataset implements it
+func setDatasetPath(ds XDatasetDef, key string, data interface{}, collections bool) error {
+	if setter, ok := ds.(interface {
+		SetPath(string, interface{}, bool) error
+	}); ok {
+		return setter.SetPath(key, data, collections)
+	}
+	ds.Set(key, data)
+	return nil
+}
+
+// setCollectionPath will set the data at the path "index>key" of any XDatasetCollectionDef, with SetPath if the collection implements it
+func setCollectionPath(dsc XDatasetCollectionDef, path string, data interface{}, collections bool) error {
+	if setter, ok := dsc.(interface {
+		SetPath(string, interface{}, bool) error
+	}); ok {
+		return setter.SetPath(path, data, collections)
+	}
+	head, rest, sub := splitPath(path)
+	index, err := strconv.Atoi(head)
+	if err != nil {
+		return errors.New("Error: the entry " + head + " of the path is not an index of the collection")
+	}
+	if index == dsc.Count() {
+		if sub {
+			ds := &XDataset{}
+			if err := ds.SetPath(rest, data, collections); err != nil {
+				return err
+			}
+			dsc.Push(ds)
+			return nil
+		}
+		ds, ok := data.(XDatasetDef)
+		if !ok {
+			return fmt.Errorf("Error: the value of type %T cannot be an entry of the collection", data)
+		}
+		dsc.Push(ds)
+		return nil
+	}
+	ds, ok := dsc.Get(index)
+	if !ok || !sub {
+		return errors.New("Error: the entry " + head + " of the collection cannot be set")
+	}
+	return setDatasetPath(ds, rest, data, collections)
+}
+
+// sliceSetPath will set the data at the path "index>key" of the slice of datasets.
+// The last entry of the path may be the index just after the end of the slice to add a new dataset
+func sliceSetPath(data *[]XDatasetDef, path string, value interface{}, collections bool) error {
+	head, rest, sub := splitPath(path)
+	index, err := strconv.Atoi(head)
+	if err != nil {
+		return errors.New("Error: the entry " + head + " of the path is not an index of the collection")
+	}
+	if index < 0 || index > len(*data) {
+		return errors.New("Error: the index " + head + " of the path is out of the collection")
+	}
+	if !sub {
+		ds, ok := value.(XDatasetDef)
+		if !ok {
+			return fmt.Errorf("Error: the value of type %T cannot be an entry of the collection", value)
+		}
+		if index == len(*data) {
+			*data = append(*data, ds)
+		} else {
+			(*data)[index] = ds
+		}
+		return nil
+	}
+	if index == len(*data) {
+		ds := &XDataset{}
+		if err := ds.SetPath(rest, value, collections); err != nil {
+			return err
+		}
+		*data = append(*data, ds)
+		return nil
+	}
+	return setDatasetPath((*data)[index], rest, value, collections)
+}
+
+// sliceDel will delete the data at the path "index>key" of the slice of datasets. If the path is only an index, the entry is removed from the slice
+func sliceDel(data *[]XDatasetDef, path string) {
+	head, rest, sub := splitPath(path)
+	index, err := strconv.Atoi(head)
+	if err != nil || index < 0 || index >= len(*data) {
+		return
+	}
+	if !sub {
+		*data = append((*data)[:index], (*data)[index+1:]...)
+		return
+	}
+	(*data)[index].Del(rest)
+}
+
+// sliceExists will check if there is a data at the path "index>key" of the slice of datasets
+func sliceExists(data []XDatasetDef, path string) bool {
+	_, ok := sliceGet(data, path)
+	return ok
+}
+
+// sliceGet will retrieve the data at the path "index>a>b" of the slice of datasets
+func sliceGet(data []XDatasetDef, path string) (interface{}, bool) {
+	head, rest, sub := splitPath(path)
+	index, err := strconv.Atoi(head)
+	if err != nil || index < 0 || index >= len(data) {
+		return nil, false
+	}
+	if !sub {
+		return data[index], true
+	}
+	return data[index].Get(rest)
+}
+
+// delPathChild will delete the data at the path rest of the child value of a dataset
+func delPathChild(child interface{}, rest string) {
+	switch c := child.(type) {
+	case XDatasetDef:
+		c.Del(rest)
+	case XDatasetCollectionDef:
+		if deleter, ok := c.(interface{ Del(string) }); ok {
+			deleter.Del(rest)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package xcore
+
+import (
+	"testing"
+)
+
+func TestXDataset_SetPath(t *testing.T) {
+	ds := &XDataset{
+		"name": "Fred",
+		"hobbies": &XDatasetCollection{
+			&XDataset{"name": "Chess"},
+		},
+	}
+	ds.Set("metadata>salary", 10)
+	ds.Set("metadata>address>city", "Mexico")
+	ds.Set("hobbies>0>level", "high")
+	ds.Set("hobbies>1>name", "Tennis")
+	ds.Set("name>first", "Fred") // name is not a dataset: the literal key is set
+
+	if _, ok := (*ds)["metadata>salary"]; ok {
+		t.Error("The path must not be a literal key")
+		return
+	}
+	if v, _ := ds.GetInt("metadata>salary"); v != 10 {
+		t.Errorf("Error setting the path metadata>salary: %v", ds)
+		return
+	}
+	if v, _ := ds.GetString("metadata>address>city"); v != "Mexico" {
+		t.Errorf("Error setting the path metadata>address>city: %v", ds)
+		return
+	}
+	if v, _ := ds.GetString("hobbies>0>level"); v != "high" {
+		t.Errorf("Error setting the path hobbies>0>level: %v", ds)
+		return
+	}
+	if v, _ := ds.GetString("hobbies>1>name"); v != "Tennis" {
+		t.Errorf("Error adding the entry hobbies>1: %v", ds)
+		return
+	}
+	if v, _ := ds.GetString("name"); v != "Fred" {
+		t.Errorf("The name must not be modified: %v", ds)
+		return
+	}
+	if v, _ := ds.GetString("name>first"); v != "Fred" || (*ds)["name>first"] != "Fred" {
+		t.Errorf("The path that cannot be followed must be set as a literal key: %v", ds)
+		return
+	}
+	if err := ds.SetPath("name>first", "Fred", false); err == nil {
+		t.Error("SetPath through a string should fail")
+		return
+	}
+	if err := ds.SetPath("hobbies>5>name", "Golf", false); err == nil {
+		t.Error("SetPath out of the collection should fail")
+		return
+	}
+
+	if err := ds.SetPath("orders>0>total", 150.5, true); err != nil {
+		t.Error(err)
+		return
+	}
+	if _, ok := ds.GetCollection("orders"); !ok {
+		t.Errorf("The numeric entry should create a collection: %v", ds)
+		return
+	}
+	if err := ds.SetPath("orders>1", &XDataset{"total": 10.0}, true); err != nil {
+		t.Error(err)
+		return
+	}
+	if v, _ := ds.GetFloat("orders>1>total"); v != 10.0 {
+		t.Errorf("Error adding the entry orders>1: %v", ds)
+		return
+	}
+	ds.SetPath("list>0>v", 1, false)
+	if _, ok := ds.GetDataset("list>0"); !ok {
+		t.Errorf("Without collections the numeric entry should create a dataset: %v", ds)
+		return
+	}
+}
+
+func TestXDataset_DelExists(t *testing.T) {
+	ds := &XDataset{
+		"metadata": &XDataset{"salary": 10, "bonus": 2},
+		"hobbies": &XDatasetCollection{
+			&XDataset{"name": "Chess", "level": "high"},
+			&XDataset{"name": "Tennis"},
+		},
+	}
+	if !ds.Exists("metadata>salary") || !ds.Exists("hobbies>1") || ds.Exists("metadata>age") || ds.Exists("hobbies>2") {
+		t.Errorf("Error in Exists: %v", ds)
+		return
+	}
+	ds.Del("metadata>salary")
+	ds.Del("hobbies>0>level")
+	ds.Del("hobbies>1")
+	ds.Del("unknown>key")
+	if ds.Exists("metadata>salary") || !ds.Exists("metadata>bonus") || ds.Exists("hobbies>0>level") || ds.Exists("hobbies>1") {
+		t.Errorf("Error in Del: %v", ds)
+		return
+	}
+	ds.Del("metadata")
+	if ds.Exists("metadata") {
+		t.Errorf("Error in Del: %v", ds)
+		return
+	}
+}
+
+func TestXDatasetTS_Path(t *testing.T) {
+	ds := NewXDatasetTS(&XDataset{})
+	ds.Set("a>b>c", "value")
+	if err := ds.SetPath("a>list>0>d", 1, true); err != nil {
+		t.Error(err)
+		return
+	}
+	if v, _ := ds.GetString("a>b>c"); v != "value" || !ds.Exists("a>list>0>d") {
+		t.Errorf("Error setting the paths: %v", ds)
+		return
+	}
+	ds.Del("a>b>c")
+	if ds.Exists("a>b>c") || !ds.Exists("a>b") {
+		t.Errorf("Error deleting the path: %v", ds)
+		return
+	}
+}
+
+func TestXDatasetCollection_Path(t *testing.T) {
+	cols := []interface {
+		XDatasetCollectionDef
+		SetPath(string, interface{}, bool) error
+		Del(string)
+		Exists(string) bool
+	}{
+		&XDatasetCollection{&XDataset{"name": "Fred"}},
+		&XDatasetCollectionTS{data: []XDatasetDef{&XDataset{"name": "Fred"}}},
+	}
+	for _, dsc := range cols {
+		if err := dsc.SetPath("0>address>city", "Mexico", false); err != nil {
+			t.Error(err)
+			return
+		}
+		if err := dsc.SetPath("1>name", "Juan", false); err != nil {
+			t.Error(err)
+			return
+		}
+		if err := dsc.SetPath("3>name", "Juan", false); err == nil {
+			t.Error("SetPath out of the collection should fail")
+			return
+		}
+		if err := dsc.SetPath("0", "Juan", false); err == nil {
+			t.Error("An entry of the collection must be a dataset")
+			return
+		}
+		if !dsc.Exists("0>address>city") || !dsc.Exists("1") || dsc.Exists("2") || dsc.Count() != 2 {
+			t.Errorf("Error setting the paths: %v", dsc)
+			return
+		}
+		dsc.Del("0>address")
+		dsc.Del("1")
+		if dsc.Exists("0>address") || dsc.Count() != 1 {
+			t.Errorf("Error deleting the paths: %v", dsc)
+			return
+		}
+	}
+}
+
+func TestXDataset_LiteralKeys(t *testing.T) {
+	// the copies keep the existing keys with ">" as they are, whatever the order of the keys
+	for i := 0; i < 50; i++ {
+		ds := NewXDataset(map[string]interface{}{"a": "x", "a>b": 1, "c": map[string]interface{}{"d": 1}, "c>d": 2}).(*XDataset)
+		if len(*ds) != 4 || (*ds)["a>b"] != 1 || (*ds)["c>d"] != 2 {
+			t.Errorf("Error: NewXDataset lost a literal key: %v", ds)
+			return
+		}
+		cloned := ds.Clone().(*XDataset)
+		if len(*cloned) != 4 || (*cloned)["a>b"] != 1 || (*cloned)["c>d"] != 2 {
+			t.Errorf("Error: Clone lost a literal key: %v", cloned)
+			return
+		}
+		merged := &XDataset{}
+		if err := merged.Merge(ds, nil); err != nil || len(*merged) != 4 || (*merged)["c>d"] != 2 {
+			t.Errorf("Error: Merge lost a literal key: %v %v", merged, err)
+			return
+		}
+		if keys := NewXDatasetTS(ds).Keys(); len(keys) != 4 {
+			t.Errorf("Error: NewXDatasetTS lost a literal key: %v", keys)
+			return
+		}
+		if s := NewXDatasetSnapshot(ds); len(s.Keys()) != 4 || (*s.data)["c>d"] != 2 {
+			t.Errorf("Error: NewXDatasetSnapshot lost a literal key: %v", s)
+			return
+		}
+	}
+}
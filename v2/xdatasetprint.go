@@ -0,0 +1,362 @@
+package xcore
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// The styles of the pretty printer
+const (
+	// PrintText is an indented "key: value" dump, the entries of the collections are [0], [1]...
+	PrintText = iota
+	// PrintJSON is an indented JSON
+	PrintJSON
+	// PrintYAML is a YAML-like dump, the entries of the collections are "- " items and the strings are quoted
+	PrintYAML
+)
+
+// XDatasetPrintOptions are the options of the pretty printer
+type XDatasetPrintOptions struct {
+	// Style is PrintText (default), PrintJSON or PrintYAML
+	Style int
+	// Indent is the indentation of each level, 2 spaces by default
+	Indent string
+	// MaxDepth is the maximum quantity of levels of nested datasets and collections printed, the deeper ones are replaced by "...". 0 is unlimited
+	MaxDepth int
+	// MaxLength is the maximum quantity of characters of the values, the longer ones are truncated with "...". 0 is unlimited
+	MaxLength int
+}
+
+// PrettyPrint will dump the value (a XDatasetDef, a XDatasetCollectionDef or any other value) into an indented readable string.
+// The keys of the datasets are sorted, so the result is always the same for the same content. The datasets that cannot list their keys are printed with String.
+// If options is nil, the text style with 2 spaces of indentation and no limits is used
+func PrettyPrint(value interface{}, options *XDatasetPrintOptions) string {
+	p := &xdatasetPrinter{}
+	if options != nil {
+		p.XDatasetPrintOptions = *options
+	}
+	if p.Indent == "" {
+		p.Indent = "  "
+	}
+	p.value(value, 0, 0)
+	return strings.TrimSuffix(p.buffer.String(), "\n")
+}
+
+// xdatasetPrinter builds the string of PrettyPrint
+type xdatasetPrinter struct {
+	XDatasetPrintOptions
+	buffer bytes.Buffer
+}
+
+// printEntry is a key of a dataset or an entry of a collection to print
+type printEntry struct {
+	key   string
+	value interface{}
+}
+
+// printEntries will return the entries of a dataset or a collection (the bools are: it is nested, it is a collection)
+func printEntries(value interface{}) ([]printEntry, bool, bool) {
+	switch v := value.(type) {
+	case XDatasetDef:
+		keys, ok := datasetKeys(v)
+		if !ok {
+			return nil, false, false
+		}
+		entries := []printEntry{}
+		for _, key := range keys {
+			child, _ := datasetKey(v, key)
+			entries = append(entries, printEntry{key: key, value: child})
+		}
+		return entries, true, false
+	case XDatasetCollectionDef:
+		entries := []printEntry{}
+		for i := 0; i < v.Count(); i++ {
+			ds, _ := v.Get(i)
+			entries = append(entries, printEntry{key: strconv.Itoa(i), value: ds})
+		}
+		return entries, true, true
+	}
+	return nil, false, false
+}
+
+// truncate will cut the string to MaxLength characters
+func (p *xdatasetPrinter) truncate(str string) string {
+	if p.MaxLength > 0 && utf8.RuneCountInString(str) > p.MaxLength {
+		return string([]rune(str)[:p.MaxLength]) + "..."
+	}
+	return str
+}
+
+// scalar will build the string of a value that is not a dataset or a collection
+func (p *xdatasetPrinter) scalar(value interface{}) string {
+	switch p.Style {
+	case PrintJSON, PrintYAML:
+		if str, ok := value.(string); ok {
+			value = p.truncate(str)
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			data, _ = json.Marshal(p.truncate(fmt.Sprint(value)))
+		}
+		return string(data)
+	}
+	if value == nil {
+		return "<nil>"
+	}
+	return p.truncate(fmt.Sprint(value))
+}
+
+// value will print the value at the level of indentation. column is the position of the cursor into the current line (0 at the beginning of a line)
+func (p *xdatasetPrinter) value(value interface{}, level int, column int) {
+	entries, nested, collection := printEntries(value)
+	if !nested {
+		if _, ok := value.(XDatasetDef); ok {
+			value = value.(XDatasetDef).String()
+		}
+		p.buffer.WriteString(p.scalar(value) + "\n")
+		return
+	}
+	empty := "{}"
+	if collection {
+		empty = "[]"
+	}
+	if len(entries) == 0 {
+		p.buffer.WriteString(empty + "\n")
+		return
+	}
+	if p.MaxDepth > 0 && level >= p.MaxDepth {
+		if p.Style == PrintJSON {
+			p.buffer.WriteString("\"...\"\n")
+		} else {
+			p.buffer.WriteString("...\n")
+		}
+		return
+	}
+	switch p.Style {
+	case PrintJSON:
+		p.json(entries, collection, level)
+	case PrintYAML:
+		p.yaml(entries, collection, level, column)
+	default:
+		p.text(entries, collection, level, column)
+	}
+}
+
+// text will print the entries with the text style
+func (p *xdatasetPrinter) text(entries []printEntry, collection bool, level int, column int) {
+	if column > 0 {
+		p.buffer.WriteString("\n")
+	}
+	indent := strings.Repeat(p.Indent, level)
+	for _, entry := range entries {
+		key := entry.key
+		if collection {
+			key = "[" + key + "]"
+		}
+		separator := ": "
+		if _, nested, _ := printEntries(entry.value); nested && !(p.MaxDepth > 0 && level+1 >= p.MaxDepth) {
+			if sub, _, _ := printEntries(entry.value); len(sub) > 0 {
+				// the nested entries start on the next line, so the line does not end with a space
+				separator = ":"
+			}
+		}
+		p.buffer.WriteString(indent + key + separator)
+		p.value(entry.value, level+1, 1)
+	}
+}
+
+// yaml will print the entries with the YAML-like style
+func (p *xdatasetPrinter) yaml(entries []printEntry, collection bool, level int, column int) {
+	indent := strings.Repeat(p.Indent, level)
+	for i, entry := range entries {
+		prefix := indent
+		if i == 0 && column > 0 {
+			// the first entry of an item of a collection continues the line of the "- "
+			prefix = ""
+		}
+		if collection {
+			p.buffer.WriteString(prefix + "- ")
+			p.value(entry.value, level+1, 1)
+			continue
+		}
+		p.buffer.WriteString(prefix + entry.key + ":")
+		if _, nested, _ := printEntries(entry.value); nested && !(p.MaxDepth > 0 && level+1 >= p.MaxDepth) {
+			if sub, _, _ := printEntries(entry.value); len(sub) > 0 {
+				p.buffer.WriteString("\n")
+				p.value(entry.value, level+1, 0)
+				continue
+			}
+		}
+		p.buffer.WriteString(" ")
+		p.value(entry.value, level+1, 1)
+	}
+}
+
+// json will print the entries with the JSON style
+func (p *xdatasetPrinter) json(entries []printEntry, collection bool, level int) {
+	open, close := "{", "}"
+	if collection {
+		open, close = "[", "]"
+	}
+	p.buffer.WriteString(open + "\n")
+	indent := strings.Repeat(p.Indent, level+1)
+	for i, entry := range entries {
+		p.buffer.WriteString(indent)
+		if !collection {
+			key, _ := json.Marshal(entry.key)
+			p.buffer.WriteString(string(key) + ": ")
+		}
+		p.value(entry.value, level+1, 1)
+		if i < len(entries)-1 {
+			// replace the end of line of the value by a comma and the end of line
+			p.buffer.Truncate(p.buffer.Len() - 1)
+			p.buffer.WriteString(",\n")
+		}
+	}
+	p.buffer.WriteString(strings.Repeat(p.Indent, level) + close + "\n")
+}
+
+// Hash will build a canonical hash (SHA-256 in hexadecimal) of the full nested content of the value (a XDatasetDef, a XDatasetCollectionDef or any other value).
+// Two values with the same content have the same hash, whatever their types (XDataset or XDatasetTS, XDatasetCollection or XDatasetCollectionTS...):
+// the keys of the datasets are sorted, the numbers are compared by value (1 and 1.0, 1000000000000000 and 1e15 are the same), and the times are compared in UTC.
+// The datasets that cannot list their keys are hashed with their String. The hash can be used as a key of a XCache or to detect changes
+func Hash(value interface{}) string {
+	h := sha256.New()
+	hashValue(h, value)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// hashValue will write the canonical encoding of the value into the hash
+func hashValue(h hash.Hash, value interface{}) {
+	switch v := value.(type) {
+	case nil:
+		io.WriteString(h, "z")
+		return
+	case string:
+		io.WriteString(h, "s"+strconv.Itoa(len(v))+":"+v)
+		return
+	case bool:
+		io.WriteString(h, "b"+strconv.FormatBool(v))
+		return
+	case time.Time:
+		io.WriteString(h, "t"+v.UTC().Format(time.RFC3339Nano))
+		return
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			io.WriteString(h, "n"+strconv.FormatInt(i, 10))
+			return
+		}
+		if f, err := v.Float64(); err == nil {
+			hashNumber(h, f)
+			return
+		}
+	}
+	entries, nested, collection := printEntries(value)
+	if nested {
+		if collection {
+			io.WriteString(h, "["+strconv.Itoa(len(entries)))
+		} else {
+			io.WriteString(h, "{"+strconv.Itoa(len(entries)))
+		}
+		for _, entry := range entries {
+			if !collection {
+				io.WriteString(h, strconv.Itoa(len(entry.key))+":"+entry.key)
+			}
+			hashValue(h, entry.value)
+		}
+		io.WriteString(h, "}")
+		return
+	}
+	if ds, ok := value.(XDatasetDef); ok {
+		io.WriteString(h, "d"+ds.String())
+		return
+	}
+	rv := reflect.ValueOf(value)
+	if isIntegerKind(rv.Kind()) {
+		io.WriteString(h, "n"+fmt.Sprint(value))
+		return
+	}
+	if f, ok := numberValue(value); ok {
+		hashNumber(h, f)
+		return
+	}
+	if rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array {
+		io.WriteString(h, "["+strconv.Itoa(rv.Len()))
+		for i := 0; i < rv.Len(); i++ {
+			hashValue(h, rv.Index(i).Interface())
+		}
+		io.WriteString(h, "}")
+		return
+	}
+	io.WriteString(h, "o"+fmt.Sprintf("%T:%v", value, value))
+}
+
+// hashNumber will write a float number into the hash: the integral values with all their digits as the integers, so 1.0 is the same as 1 and 1e15 the same as 1000000000000000
+func hashNumber(h hash.Hash, f float64) {
+	if f == math.Trunc(f) && !math.IsInf(f, 0) {
+		io.WriteString(h, "n"+strconv.FormatFloat(f, 'f', 0, 64))
+		return
+	}
+	io.WriteString(h, "n"+strconv.FormatFloat(f, 'g', -1, 64))
+}
+
+// Pretty will dump the XDataset into an indented readable string (see PrettyPrint)
+func (d *XDataset) Pretty(options *XDatasetPrintOptions) string {
+	return PrettyPrint(d, options)
+}
+
+// Hash will build the canonical hash of the full content of the XDataset (see Hash)
+func (d *XDataset) Hash() string {
+	return Hash(d)
+}
+
+// Pretty will dump the XDatasetTS into an indented readable string (see PrettyPrint)
+func (ds *XDatasetTS) Pretty(options *XDatasetPrintOptions) string {
+	ds.mutex.RLock()
+	defer ds.mutex.RUnlock()
+	return PrettyPrint(ds.data, options)
+}
+
+// Hash will build the canonical hash of the full content of the XDatasetTS (see Hash)
+func (ds *XDatasetTS) Hash() string {
+	ds.mutex.RLock()
+	defer ds.mutex.RUnlock()
+	return Hash(ds.data)
+}
+
+// Pretty will dump the collection into an indented readable string (see PrettyPrint)
+func (d *XDatasetCollection) Pretty(options *XDatasetPrintOptions) string {
+	return PrettyPrint(d, options)
+}
+
+// Hash will build the canonical hash of the full content of the collection (see Hash)
+func (d *XDatasetCollection) Hash() string {
+	return Hash(d)
+}
+
+// Pretty will dump the collection into an indented readable string (see PrettyPrint)
+func (dc *XDatasetCollectionTS) Pretty(options *XDatasetPrintOptions) string {
+	dc.mutex.RLock()
+	defer dc.mutex.RUnlock()
+	data := XDatasetCollection(dc.data)
+	return PrettyPrint(&data, options)
+}
+
+// Hash will build the canonical hash of the full content of the collection (see Hash)
+func (dc *XDatasetCollectionTS) Hash() string {
+	dc.mutex.RLock()
+	defer dc.mutex.RUnlock()
+	data := XDatasetCollection(dc.data)
+	return Hash(&data)
+}
@@ -0,0 +1,97 @@
+package xcore
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestPrettyPrint(t *testing.T) {
+	ds := &XDataset{
+		"name": "Fred",
+		"age":  42,
+		"hobbies": &XDatasetCollection{
+			&XDataset{"name": "Football", "sport": true},
+			&XDataset{"name": "Music", "sport": false},
+		},
+		"metadata": &XDataset{"color": "blue", "tags": &XDataset{}},
+	}
+
+	text := ds.Pretty(nil)
+	if text != "age: 42\nhobbies:\n  [0]:\n    name: Football\n    sport: true\n  [1]:\n    name: Music\n    sport: false\nmetadata:\n  color: blue\n  tags: {}\nname: Fred" {
+		t.Errorf("Error in the text style:\n%s", text)
+	}
+
+	js := ds.Pretty(&XDatasetPrintOptions{Style: PrintJSON})
+	if js != "{\n  \"age\": 42,\n  \"hobbies\": [\n    {\n      \"name\": \"Football\",\n      \"sport\": true\n    },\n    {\n      \"name\": \"Music\",\n      \"sport\": false\n    }\n  ],\n  \"metadata\": {\n    \"color\": \"blue\",\n    \"tags\": {}\n  },\n  \"name\": \"Fred\"\n}" {
+		t.Errorf("Error in the JSON style:\n%s", js)
+	}
+
+	yaml := ds.Pretty(&XDatasetPrintOptions{Style: PrintYAML})
+	if yaml != "age: 42\nhobbies:\n  - name: \"Football\"\n    sport: true\n  - name: \"Music\"\n    sport: false\nmetadata:\n  color: \"blue\"\n  tags: {}\nname: \"Fred\"" {
+		t.Errorf("Error in the YAML style:\n%s", yaml)
+	}
+
+	limited := ds.Pretty(&XDatasetPrintOptions{Style: PrintYAML, MaxDepth: 1, MaxLength: 2, Indent: "\t"})
+	if limited != "age: 42\nhobbies: ...\nmetadata: ...\nname: \"Fr...\"" {
+		t.Errorf("Error in the limits:\n%s", limited)
+	}
+
+	// the same content gives the same dump, whatever the types
+	ts := NewXDatasetTS(ds.Clone())
+	if ts.Pretty(nil) != text {
+		t.Errorf("Error in the text style of the XDatasetTS:\n%s", ts.Pretty(nil))
+	}
+	if PrettyPrint("value", nil) != "value" || PrettyPrint(nil, &XDatasetPrintOptions{Style: PrintJSON}) != "null" {
+		t.Error("Error in the print of a scalar")
+	}
+}
+
+func TestHash(t *testing.T) {
+	ds := &XDataset{
+		"name":    "Fred",
+		"age":     42,
+		"hobbies": &XDatasetCollection{&XDataset{"name": "Football"}},
+		"list":    []string{"a", "b"},
+	}
+	hash := ds.Hash()
+	if len(hash) != 64 {
+		t.Errorf("Error in the hash: %s", hash)
+	}
+
+	// same content, other types and numbers
+	other := NewXDatasetTS(&XDataset{
+		"list":    []interface{}{"a", "b"},
+		"hobbies": &XDatasetCollection{&XDataset{"name": "Football"}},
+		"age":     42.0,
+		"name":    "Fred",
+	})
+	if other.Hash() != hash {
+		t.Errorf("Error: the same content has another hash: %s %s", other.Hash(), hash)
+	}
+
+	// any change in the nested content changes the hash
+	other.Set("hobbies>0>name", "Music")
+	if other.Hash() == hash {
+		t.Error("Error: a nested change does not change the hash")
+	}
+	if Hash(&XDataset{"a": "bc"}) == Hash(&XDataset{"ab": "c"}) || Hash(&XDataset{"a": "1"}) == Hash(&XDataset{"a": 1}) {
+		t.Error("Error: different contents have the same hash")
+	}
+	// the integral numbers are hashed the same whatever their type and size
+	for _, pair := range [][2]interface{}{{int64(1e15), 1e15}, {int64(1 << 60), float64(1 << 60)}, {uint64(1e18), json.Number("1e18")}, {1.5, json.Number("1.50")}} {
+		if Hash(&XDataset{"n": pair[0]}) != Hash(&XDataset{"n": pair[1]}) {
+			t.Errorf("Error: the same number has another hash: %v %v", pair[0], pair[1])
+		}
+	}
+	if Hash(&XDataset{"n": int64(1e15)}) == Hash(&XDataset{"n": 1e15 + 1}) {
+		t.Error("Error: different numbers have the same hash")
+	}
+
+	col := &XDatasetCollection{&XDataset{"a": 1}, &XDataset{"b": 2}}
+	colts := &XDatasetCollectionTS{}
+	colts.Push(&XDataset{"a": 1})
+	colts.Push(&XDataset{"b": 2})
+	if col.Hash() != colts.Hash() || col.Pretty(nil) != colts.Pretty(nil) {
+		t.Errorf("Error in the hash of the collections: %s %s", col.Hash(), colts.Hash())
+	}
+}
@@ -0,0 +1,187 @@
+package xcore
+
+import (
+	"strings"
+)
+
+// isQuery will check if the path key uses the query syntax (wildcards or predicates)
+func isQuery(key string) bool {
+	return strings.ContainsAny(key, "*[")
+}
+
+// queryValue will select all the values that match the query from the root value (a XDatasetDef or a XDatasetCollectionDef).
+// The entries of the query are separated by ">":
+//
+// - name: the value of the key of the datasets, or the entry of the collections if name is an index (or a key of a keyed collection)
+//
+// - *: all the values of the datasets (ordered by key) and all the entries of the collections
+//
+// - **: the value itself and all its nested values, at any depth (the collections are not selected, but their entries are)
+//
+// - [field=value], [field!=value], [field]: the datasets (or the entries of the collections) whose field is (is not) the value, or exists
+func queryValue(root interface{}, query string) []interface{} {
+	values := []interface{}{root}
+	for _, entry := range strings.Split(query, ">") {
+		next := []interface{}{}
+		for _, value := range values {
+			next = append(next, queryEntry(value, entry)...)
+		}
+		values = next
+		if len(values) == 0 {
+			break
+		}
+	}
+	return values
+}
+
+// queryEntry will select the values matching the entry of the query into the value
+func queryEntry(value interface{}, entry string) []interface{} {
+	result := []interface{}{}
+	switch {
+	case entry == "*":
+		result = append(result, queryChildren(value)...)
+	case entry == "**":
+		// the collections are not selected, only their entries, so a predicate does not select twice the same entry
+		if _, ok := value.(XDatasetCollectionDef); !ok {
+			result = append(result, value)
+		}
+		for _, child := range queryChildren(value) {
+			result = append(result, queryEntry(child, "**")...)
+		}
+	case strings.HasPrefix(entry, "[") && strings.HasSuffix(entry, "]"):
+		predicate := entry[1 : len(entry)-1]
+		switch v := value.(type) {
+		case XDatasetDef:
+			if queryMatch(v, predicate) {
+				result = append(result, v)
+			}
+		case XDatasetCollectionDef:
+			for i := 0; i < v.Count(); i++ {
+				if ds, _ := v.Get(i); ds != nil && queryMatch(ds, predicate) {
+					result = append(result, ds)
+				}
+			}
+		}
+	default:
+		switch v := value.(type) {
+		case XDatasetDef:
+			if child, ok := v.Get(entry); ok {
+				result = append(result, child)
+			}
+		case XDatasetCollectionDef:
+			if ds, ok := collectionEntry(v, entry); ok {
+				result = append(result, ds)
+			}
+		}
+	}
+	return result
+}
+
+// queryChildren will return the values of the dataset (ordered by key) or the entries of the collection
+func queryChildren(value interface{}) []interface{} {
+	result := []interface{}{}
+	switch v := value.(type) {
+	case XDatasetDef:
+		keys, _ := datasetKeys(v)
+		for _, key := range keys {
+			child, _ := datasetKey(v, key)
+			result = append(result, child)
+		}
+	case XDatasetCollectionDef:
+		for i := 0; i < v.Count(); i++ {
+			ds, _ := v.Get(i)
+			result = append(result, ds)
+		}
+	}
+	return result
+}
+
+// queryMatch will check if the dataset matches the predicate field=value, field!=value or field
+func queryMatch(ds XDatasetDef, predicate string) bool {
+	if pos := strings.Index(predicate, "!="); pos >= 0 {
+		value, ok := ds.GetString(predicate[:pos])
+		return !ok || value != predicate[pos+2:]
+	}
+	if pos := strings.Index(predicate, "="); pos >= 0 {
+		value, ok := ds.GetString(predicate[:pos])
+		return ok && value == predicate[pos+1:]
+	}
+	_, ok := ds.Get(predicate)
+	return ok
+}
+
+// queryStack will build a collection with the datasets that match the query into the stack of data of a template.
+// The query is applied to each level of the stack from the last one, the first level with results is used
+func queryStack(datacol XDatasetCollectionDef, query string) XDatasetCollectionDef {
+	for i := datacol.Count() - 1; i >= 0; i-- {
+		ds, _ := datacol.Get(i)
+		values := queryValue(ds, query)
+		if len(values) == 0 {
+			continue
+		}
+		dsc := &XDatasetCollection{}
+		for _, value := range values {
+			if entry, ok := value.(XDatasetDef); ok {
+				dsc.Push(entry)
+			}
+		}
+		return dsc
+	}
+	return nil
+}
+
+// queryFirst will return the first value of the result of the query
+func queryFirst(values []interface{}) (interface{}, bool) {
+	if len(values) == 0 {
+		return nil, false
+	}
+	return values[0], true
+}
+
+// Query will select all the values of the XDataset that match the query, for instance "hobbies>*>name", "hobbies>[sport=yes]>name" or "orders>**>total".
+// The entries of the query are separated by ">" and may be a key (or an index of a collection), "*" for all the values of a dataset or a collection,
+// "**" for all the nested values at any depth (except the collections themselves), and [field=value], [field!=value] or [field] to filter the datasets.
+func (d *XDataset) Query(query string) []interface{} {
+	return queryValue(d, query)
+}
+
+// QueryOne will return the first value of the XDataset that matches the query (see Query), or false if there is none
+func (d *XDataset) QueryOne(query string) (interface{}, bool) {
+	return queryFirst(queryValue(d, query))
+}
+
+// Query will select all the values of the XDatasetTS that match the query (see XDataset.Query)
+func (ds *XDatasetTS) Query(query string) []interface{} {
+	ds.mutex.RLock()
+	defer ds.mutex.RUnlock()
+	return queryValue(ds.data, query)
+}
+
+// QueryOne will return the first value of the XDatasetTS that matches the query (see XDataset.Query), or false if there is none
+func (ds *XDatasetTS) QueryOne(query string) (interface{}, bool) {
+	return queryFirst(ds.Query(query))
+}
+
+// Query will select all the values of the collection that match the query, the first entry of the query applies to the entries of the collection:
+// "*>name", "[sport=yes]>name", "0>name", "**>total" (see XDataset.Query)
+func (d *XDatasetCollection) Query(query string) []interface{} {
+	return queryValue(d, query)
+}
+
+// QueryOne will return the first value of the collection that matches the query (see XDatasetCollection.Query), or false if there is none
+func (d *XDatasetCollection) QueryOne(query string) (interface{}, bool) {
+	return queryFirst(queryValue(d, query))
+}
+
+// Query will select all the values of the collection that match the query (see XDatasetCollection.Query)
+func (dc *XDatasetCollectionTS) Query(query string) []interface{} {
+	dc.mutex.RLock()
+	defer dc.mutex.RUnlock()
+	data := XDatasetCollection(dc.data)
+	return queryValue(&data, query)
+}
+
+// QueryOne will return the first value of the collection that matches the query (see XDatasetCollection.Query), or false if there is none
+func (dc *XDatasetCollectionTS) QueryOne(query string) (interface{}, bool) {
+	return queryFirst(dc.Query(query))
+}
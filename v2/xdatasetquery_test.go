@@ -0,0 +1,80 @@
+package xcore
+
+import (
+	"fmt"
+	"testing"
+)
+
+func getQueryDataset() *XDataset {
+	return &XDataset{
+		"name": "Fred",
+		"hobbies": &XDatasetCollection{
+			&XDataset{"name": "Football", "sport": "yes"},
+			&XDataset{"name": "Chess", "sport": "no"},
+			&XDataset{"name": "Tennis", "sport": "yes"},
+		},
+		"orders": &XDatasetCollection{
+			&XDataset{"id": 1, "total": 100, "lines": &XDatasetCollection{&XDataset{"total": 60}, &XDataset{"total": 40}}},
+			&XDataset{"id": 2, "total": 50},
+		},
+		"address": &XDataset{"city": "Mexico", "zip": "01000"},
+	}
+}
+
+func TestXDataset_Query(t *testing.T) {
+	ds := getQueryDataset()
+	tests := map[string]string{
+		"hobbies>*>name":            "[Football Chess Tennis]",
+		"hobbies>[sport=yes]>name":  "[Football Tennis]",
+		"hobbies>[sport!=yes]>name": "[Chess]",
+		"hobbies>[level]>name":      "[]",
+		"hobbies>1>name":            "[Chess]",
+		"orders>**>total":           "[100 60 40 50]",
+		"orders>[id=2]>total":       "[50]",
+		"address>*":                 "[Mexico 01000]",
+		"**>city":                   "[Mexico]",
+		"name":                      "[Fred]",
+		"unknown>*":                 "[]",
+		"address>[city=Mexico]>zip": "[01000]",
+		"address>[city=Paris]>zip":  "[]",
+		"hobbies>*>name>*":          "[]",
+	}
+	for query, expected := range tests {
+		if result := fmt.Sprint(ds.Query(query)); result != expected {
+			t.Errorf("Error in query %s: %s", query, result)
+		}
+	}
+	if v, ok := ds.QueryOne("hobbies>[sport=yes]>name"); !ok || v != "Football" {
+		t.Errorf("Error in QueryOne: %v", v)
+	}
+	if _, ok := ds.QueryOne("hobbies>[sport=maybe]>name"); ok {
+		t.Error("QueryOne should not find anything")
+	}
+
+	ts := NewXDatasetTS(ds)
+	if result := fmt.Sprint(ts.Query("hobbies>[sport=yes]>name")); result != "[Football Tennis]" {
+		t.Errorf("Error in XDatasetTS query: %s", result)
+	}
+
+	dsc, _ := ds.GetCollection("hobbies")
+	if result := fmt.Sprint(dsc.(*XDatasetCollection).Query("[sport=no]>name")); result != "[Chess]" {
+		t.Errorf("Error in XDatasetCollection query: %s", result)
+	}
+	dscts := &XDatasetCollectionTS{data: []XDatasetDef(*dsc.(*XDatasetCollection))}
+	if v, ok := dscts.QueryOne("*>name"); !ok || v != "Football" {
+		t.Errorf("Error in XDatasetCollectionTS query: %v", v)
+	}
+}
+
+func TestXTemplate_Query(t *testing.T) {
+	tmpl, _ := NewXTemplateFromString(`Sports: @@hobbies>[sport=yes]:hobby@@
+[[hobby]]{{name}}[[]][[hobby.separator]], [[]]
+[[hobby.none]]none[[]]
+Lines: @@orders>**>[total]:line@@
+[[line]]{{total}} [[]]
+Nothing: @@hobbies>[sport=maybe]:hobby@@`)
+	result := tmpl.Execute(getQueryDataset())
+	if result != "Sports: Football, Tennis\nLines: 100 60 40 50 \nNothing: none" {
+		t.Errorf("Error in template query: %s", result)
+	}
+}
@@ -0,0 +1,300 @@
+package xcore
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// The types of the fields of a XDatasetSchema
+const (
+	// SchemaAny accepts any type of value
+	SchemaAny = ""
+	// SchemaString is a string
+	SchemaString = "string"
+	// SchemaInt is an integer (int, int8... uint64)
+	SchemaInt = "int"
+	// SchemaFloat is a number (float32, float64 or any integer)
+	SchemaFloat = "float"
+	// SchemaBool is a bool
+	SchemaBool = "bool"
+	// SchemaTime is a time.Time
+	SchemaTime = "time"
+	// SchemaDataset is a XDatasetDef, validated with the Schema of the field if any
+	SchemaDataset = "dataset"
+	// SchemaCollection is a XDatasetCollectionDef, each entry validated with the Schema of the field if any
+	SchemaCollection = "collection"
+)
+
+// XDatasetField is the definition of a field of a XDatasetSchema
+type XDatasetField struct {
+	// Type is the type of the value (SchemaString, SchemaInt...). SchemaAny accepts any value
+	Type string
+	// Required is true if the key must exist with a non nil value
+	Required bool
+	// Min and Max are the limits of the value for the numbers, of the length for the strings and of the quantity of entries for the collections.
+	// They are ignored for the other types
+	Min *float64
+	Max *float64
+	// Pattern is the regular expression that the value as a string must match
+	Pattern *regexp.Regexp
+	// Enum is the list of the allowed values
+	Enum []interface{}
+	// Schema is the schema of the nested dataset, or of each entry of the collection
+	Schema *XDatasetSchema
+}
+
+// XDatasetSchema is the description of the keys of a dataset, to validate the data before using it
+//
+//	schema := &xcore.XDatasetSchema{
+//	  Fields: map[string]*xcore.XDatasetField{
+//	    "name": {Type: xcore.SchemaString, Required: true, Pattern: regexp.MustCompile(`^[A-Z]`)},
+//	    "age":  {Type: xcore.SchemaInt, Min: xcore.Bound(0), Max: xcore.Bound(150)},
+//	  },
+//	}
+//	violations := schema.Validate(data)
+type XDatasetSchema struct {
+	Fields map[string]*XDatasetField
+	// Strict is true if the keys that are not into Fields are violations. The dataset must be able to list its keys (XDataset, XDatasetTS...)
+	Strict bool
+}
+
+// XDatasetViolation is a value of the dataset that does not follow the schema
+type XDatasetViolation struct {
+	Path    string
+	Message string
+}
+
+// String will build the description of the violation
+func (v XDatasetViolation) String() string {
+	return v.Path + ": " + v.Message
+}
+
+// XDatasetViolations is the list of violations found by the validation of a dataset
+type XDatasetViolations []XDatasetViolation
+
+// Error will build the description of all the violations, so the list can be used as an error
+func (v XDatasetViolations) Error() string {
+	sdata := []string{}
+	for _, violation := range v {
+		sdata = append(sdata, violation.String())
+	}
+	return "Error: invalid dataset: " + strings.Join(sdata, "; ")
+}
+
+// Bound will build a limit for the Min and Max of a XDatasetField
+func Bound(value float64) *float64 {
+	return &value
+}
+
+// Validate will check the dataset against the schema.
+// Returns the list of all the violations with the path "a>b>c" of the values, or nil if the dataset is valid
+func (s *XDatasetSchema) Validate(ds XDatasetDef) XDatasetViolations {
+	violations := XDatasetViolations{}
+	s.validate(&violations, "", ds, false)
+	if len(violations) == 0 {
+		return nil
+	}
+	return violations
+}
+
+// Coerce will convert the values of the dataset to the types of the schema when possible, with the rules of GetInt, GetFloat and GetBool
+// (for instance "12" to 12 for SchemaInt, or 1 to true for SchemaBool), then check the dataset against the schema.
+// The values are modified into the dataset. Returns the list of all the violations, or nil if the dataset is valid
+func (s *XDatasetSchema) Coerce(ds XDatasetDef) XDatasetViolations {
+	violations := XDatasetViolations{}
+	s.validate(&violations, "", ds, true)
+	if len(violations) == 0 {
+		return nil
+	}
+	return violations
+}
+
+// validate will add the violations of the dataset at the path prefix
+func (s *XDatasetSchema) validate(violations *XDatasetViolations, prefix string, ds XDatasetDef, coerce bool) {
+	add := func(key string, message string) {
+		*violations = append(*violations, XDatasetViolation{Path: prefix + key, Message: message})
+	}
+	if s.Strict {
+		keys, ok := datasetKeys(ds)
+		if !ok {
+			add("", fmt.Sprintf("the dataset %T cannot list its keys", ds))
+		}
+		for _, key := range keys {
+			if _, ok := s.Fields[key]; !ok {
+				add(key, "unknown key")
+			}
+		}
+	}
+	for _, key := range sortedFieldKeys(s.Fields) {
+		field := s.Fields[key]
+		value, ok := datasetKey(ds, key)
+		if !ok || value == nil {
+			if field.Required {
+				add(key, "required")
+			}
+			continue
+		}
+		if coerce {
+			if converted, ok := coerceValue(value, field.Type); ok {
+				value = converted
+				setDatasetKey(ds, key, value)
+			}
+		}
+		field.validate(violations, prefix+key, value, coerce)
+	}
+}
+
+// validate will add the violations of the value at the path
+func (f *XDatasetField) validate(violations *XDatasetViolations, path string, value interface{}, coerce bool) {
+	add := func(message string) {
+		*violations = append(*violations, XDatasetViolation{Path: path, Message: message})
+	}
+	rv := reflect.ValueOf(value)
+	size, sized := 0.0, true
+	switch f.Type {
+	case SchemaAny:
+		sized = false
+	case SchemaString:
+		str, ok := value.(string)
+		if !ok {
+			add(fmt.Sprintf("must be a string, not %T", value))
+			return
+		}
+		size = float64(utf8.RuneCountInString(str))
+	case SchemaInt:
+		if !isIntegerKind(rv.Kind()) {
+			add(fmt.Sprintf("must be an integer, not %T", value))
+			return
+		}
+		size, _ = convertFloat(value)
+	case SchemaFloat:
+		if !isNumberKind(rv.Kind()) {
+			add(fmt.Sprintf("must be a number, not %T", value))
+			return
+		}
+		size, _ = convertFloat(value)
+	case SchemaBool:
+		if _, ok := value.(bool); !ok {
+			add(fmt.Sprintf("must be a bool, not %T", value))
+			return
+		}
+		sized = false
+	case SchemaTime:
+		if _, ok := value.(time.Time); !ok {
+			add(fmt.Sprintf("must be a time, not %T", value))
+			return
+		}
+		sized = false
+	case SchemaDataset:
+		ds, ok := value.(XDatasetDef)
+		if !ok {
+			add(fmt.Sprintf("must be a dataset, not %T", value))
+			return
+		}
+		sized = false
+		if f.Schema != nil {
+			f.Schema.validate(violations, path+">", ds, coerce)
+		}
+	case SchemaCollection:
+		dsc, ok := value.(XDatasetCollectionDef)
+		if !ok {
+			add(fmt.Sprintf("must be a collection, not %T", value))
+			return
+		}
+		size = float64(dsc.Count())
+		if f.Schema != nil {
+			for i := 0; i < dsc.Count(); i++ {
+				ds, _ := dsc.Get(i)
+				f.Schema.validate(violations, path+">"+strconv.Itoa(i)+">", ds, coerce)
+			}
+		}
+	default:
+		add("unknown type of field " + f.Type)
+		return
+	}
+	if sized && f.Min != nil && size < *f.Min {
+		add(fmt.Sprintf("must be at least %v", *f.Min))
+	}
+	if sized && f.Max != nil && size > *f.Max {
+		add(fmt.Sprintf("must be at most %v", *f.Max))
+	}
+	if f.Pattern != nil && !f.Pattern.MatchString(fmt.Sprint(value)) {
+		add("must match " + f.Pattern.String())
+	}
+	if len(f.Enum) > 0 {
+		for _, v := range f.Enum {
+			if reflect.DeepEqual(v, value) {
+				return
+			}
+		}
+		add(fmt.Sprintf("must be one of %v", f.Enum))
+	}
+}
+
+// coerceValue will convert the value to the type of the field with the rules of the Get* functions, the integers only from integral values. Returns false if the value is not converted
+func coerceValue(value interface{}, t string) (interface{}, bool) {
+	_, isstring := value.(string)
+	_, isnumber := numberValue(value)
+	if _, ok := value.(json.Number); ok {
+		isnumber = true
+	}
+	switch t {
+	case SchemaInt:
+		// only the integral values are converted, so no data is lost: the others stay as they are and are reported by the validation
+		switch v := value.(type) {
+		case string:
+			if i, err := strconv.Atoi(strings.TrimSpace(v)); err == nil {
+				return i, true
+			}
+			return nil, false
+		case json.Number:
+			if i, err := strconv.Atoi(v.String()); err == nil {
+				return i, true
+			}
+			return nil, false
+		}
+		if f, ok := numberValue(value); ok && f == math.Trunc(f) {
+			return convertInt(value)
+		}
+	case SchemaFloat:
+		if isstring || isnumber {
+			return convertFloat(value)
+		}
+	case SchemaBool:
+		if isstring || isnumber {
+			return convertBool(value)
+		}
+	case SchemaTime:
+		if isstring || isnumber {
+			return convertTime(value)
+		}
+	case SchemaString:
+		if isnumber {
+			return convertString(value)
+		}
+	}
+	return nil, false
+}
+
+// isIntegerKind will check if the kind is an integer
+func isIntegerKind(k reflect.Kind) bool {
+	return isNumberKind(k) && k != reflect.Float32 && k != reflect.Float64
+}
+
+// sortedFieldKeys will return the sorted keys of the fields, so the violations are always in the same order
+func sortedFieldKeys(fields map[string]*XDatasetField) []string {
+	keys := []string{}
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
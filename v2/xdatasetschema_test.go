@@ -0,0 +1,113 @@
+package xcore
+
+import (
+	"regexp"
+	"testing"
+	"time"
+)
+
+var testSchema = &XDatasetSchema{
+	Fields: map[string]*XDatasetField{
+		"name":    {Type: SchemaString, Required: true, Min: Bound(2), Pattern: regexp.MustCompile(`^[A-Z]`)},
+		"age":     {Type: SchemaInt, Min: Bound(0), Max: Bound(150)},
+		"salary":  {Type: SchemaFloat},
+		"active":  {Type: SchemaBool},
+		"hired":   {Type: SchemaTime},
+		"status":  {Enum: []interface{}{"new", "active", "closed"}},
+		"address": {Type: SchemaDataset, Schema: &XDatasetSchema{Fields: map[string]*XDatasetField{"city": {Type: SchemaString, Required: true}}}},
+		"hobbies": {Type: SchemaCollection, Max: Bound(2), Schema: &XDatasetSchema{Strict: true, Fields: map[string]*XDatasetField{"name": {Type: SchemaString, Required: true}}}},
+	},
+}
+
+func TestXDatasetSchema_Validate(t *testing.T) {
+	ds := &XDataset{
+		"name":    "Fred",
+		"age":     30,
+		"salary":  3500,
+		"active":  true,
+		"hired":   time.Now(),
+		"status":  "active",
+		"address": &XDataset{"city": "Mexico"},
+		"hobbies": &XDatasetCollection{&XDataset{"name": "Chess"}},
+	}
+	if violations := testSchema.Validate(ds); violations != nil {
+		t.Errorf("The dataset should be valid: %v", violations)
+		return
+	}
+
+	ds = &XDataset{
+		"name":    "f",
+		"age":     "thirty",
+		"salary":  "high",
+		"active":  "yes",
+		"hired":   "yesterday",
+		"status":  "unknown",
+		"address": &XDataset{},
+		"hobbies": &XDatasetCollection{&XDataset{"name": "Chess"}, &XDataset{"level": 1}, &XDataset{"name": "Golf"}},
+	}
+	violations := testSchema.Validate(ds)
+	if violations.Error() != "Error: invalid dataset: active: must be a bool, not string; address>city: required; age: must be an integer, not string; "+
+		"hired: must be a time, not string; hobbies>1>level: unknown key; hobbies>1>name: required; hobbies: must be at most 2; "+
+		"name: must be at least 2; name: must match ^[A-Z]; salary: must be a number, not string; status: must be one of [new active closed]" {
+		t.Errorf("Error validating the dataset: %v", violations)
+		return
+	}
+
+	violations = testSchema.Validate(&XDataset{"age": 200, "hobbies": "none"})
+	if violations.Error() != "Error: invalid dataset: age: must be at most 150; hobbies: must be a collection, not string; name: required" {
+		t.Errorf("Error validating the dataset: %v", violations)
+		return
+	}
+}
+
+func TestXDatasetSchema_Coerce(t *testing.T) {
+	ds := &XDataset{
+		"name":    "Fred",
+		"age":     " 30 ",
+		"salary":  "3500.5",
+		"active":  1,
+		"hired":   "2020-01-02T03:04:05Z",
+		"address": &XDataset{"city": 1234},
+	}
+	schema := &XDatasetSchema{Fields: map[string]*XDatasetField{
+		"name":    {Type: SchemaString},
+		"age":     {Type: SchemaInt},
+		"salary":  {Type: SchemaFloat},
+		"active":  {Type: SchemaBool},
+		"hired":   {Type: SchemaTime},
+		"address": {Type: SchemaDataset, Schema: &XDatasetSchema{Fields: map[string]*XDatasetField{"city": {Type: SchemaString}}}},
+	}}
+	if violations := schema.Coerce(ds); violations != nil {
+		t.Errorf("The dataset should be coerced: %v", violations)
+		return
+	}
+	if (*ds)["age"] != 30 || (*ds)["salary"] != 3500.5 || (*ds)["active"] != true {
+		t.Errorf("Error coercing the values: %v", ds)
+		return
+	}
+	if hired, _ := ds.GetTime("hired"); hired.Year() != 2020 {
+		t.Errorf("Error coercing the time: %v", ds)
+		return
+	}
+	if city, _ := ds.Get("address>city"); city != "1234" {
+		t.Errorf("Error coercing the nested value: %v", ds)
+		return
+	}
+
+	for _, age := range []interface{}{"12.7", 12.7} {
+		data := &XDataset{"age": age}
+		if violations := schema.Coerce(data); violations == nil || (*data)["age"] != age {
+			t.Errorf("Error: the decimal age %v must not be coerced to an integer: %v", age, data)
+		}
+	}
+	data := &XDataset{"age": 12.0}
+	if violations := schema.Coerce(data); violations != nil || (*data)["age"] != 12 {
+		t.Errorf("Error coercing an integral float: %v %v", violations, data)
+	}
+
+	violations := schema.Coerce(&XDataset{"age": "abc"})
+	if violations.Error() != "Error: invalid dataset: age: must be an integer, not string" {
+		t.Errorf("Error coercing the dataset: %v", violations)
+		return
+	}
+}
@@ -0,0 +1,482 @@
+package xcore
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// XDatasetSnapshot is an immutable dataset: once built, its content (and the content of its nested datasets and collections) never changes,
+// so it can be read by any quantity of goroutines without locks.
+// Set and Del do nothing: With and Without build a new version of the snapshot, sharing all the nested values that are not modified (structural sharing).
+// The slices of values ([]string, []int...) are copied when the snapshot is built and by Mutable. They are shared between the versions and must not be modified by the readers.
+type XDatasetSnapshot struct {
+	data *XDataset
+}
+
+// NewXDatasetSnapshot will build an immutable deep copy of the dataset. The nested datasets and collections are frozen too.
+// The dataset must be able to list its keys (XDataset, XDatasetTS...), or the snapshot is empty. A nil dataset builds an empty snapshot
+func NewXDatasetSnapshot(ds XDatasetDef) *XDatasetSnapshot {
+	if s, ok := ds.(*XDatasetSnapshot); ok {
+		return s
+	}
+	data := &XDataset{}
+	if ds != nil {
+		keys, _ := datasetKeys(ds)
+		for _, key := range keys {
+			value, _ := datasetKey(ds, key)
+			(*data)[key] = freezeValue(value)
+		}
+	}
+	return &XDatasetSnapshot{data: data}
+}
+
+// freezeValue will build the immutable version of a value: a XDatasetSnapshot for the datasets, a read only collection of snapshots for the collections.
+// The datasets that cannot list their keys are cloned, and the slices of values are copied so they are not shared with the source
+func freezeValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case *XDatasetSnapshot, *snapshotCollection:
+		return v
+	case XDatasetDef:
+		if _, ok := datasetKeys(v); !ok {
+			return v.Clone()
+		}
+		return NewXDatasetSnapshot(v)
+	case XDatasetCollectionDef:
+		data := make([]XDatasetDef, 0, v.Count())
+		for i := 0; i < v.Count(); i++ {
+			ds, _ := v.Get(i)
+			// a nil entry stays nil
+			frozen, _ := freezeValue(ds).(XDatasetDef)
+			data = append(data, frozen)
+		}
+		return &snapshotCollection{data: data}
+	}
+	return copySlice(value, freezeValue)
+}
+
+// thawValue will build a mutable deep copy of a frozen value
+func thawValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case *XDatasetSnapshot:
+		return v.Mutable()
+	case *snapshotCollection:
+		dsc := &XDatasetCollection{}
+		for _, ds := range v.data {
+			thawed, _ := thawValue(ds).(XDatasetDef)
+			dsc.Push(thawed)
+		}
+		return dsc
+	}
+	return copySlice(value, thawValue)
+}
+
+// copySlice will copy the slice of values, so it is not shared between a snapshot and a mutable dataset. The entries of a []interface{} are copied with the function.
+// Any other value is returned as is
+func copySlice(value interface{}, copyValue func(interface{}) interface{}) interface{} {
+	switch v := value.(type) {
+	case []string:
+		return append([]string{}, v...)
+	case []bool:
+		return append([]bool{}, v...)
+	case []int:
+		return append([]int{}, v...)
+	case []float64:
+		return append([]float64{}, v...)
+	case []time.Time:
+		return append([]time.Time{}, v...)
+	case []interface{}:
+		data := make([]interface{}, len(v))
+		for i, val := range v {
+			data[i] = copyValue(val)
+		}
+		return data
+	}
+	return value
+}
+
+// With will build a new version of the snapshot with the value at the path key "a>b>c" (the entries of the collections are indexes).
+// Only the datasets and collections on the path are copied, all the other values are shared with the original snapshot.
+// The missing datasets of the path are created. Returns an error if an entry of the path is not a dataset or a collection, or an index is out of range
+func (d *XDatasetSnapshot) With(key string, value interface{}) (*XDatasetSnapshot, error) {
+	head, rest, sub := splitPath(key)
+	var err error
+	if sub {
+		child, _ := d.data.Get(head)
+		value, err = snapshotWith(child, rest, value)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		value = freezeValue(value)
+	}
+	data := make(XDataset, len(*d.data)+1)
+	for k, v := range *d.data {
+		data[k] = v
+	}
+	data[head] = value
+	return &XDatasetSnapshot{data: &data}, nil
+}
+
+// snapshotWith will build the new version of the frozen child with the value at the path
+func snapshotWith(child interface{}, path string, value interface{}) (interface{}, error) {
+	switch c := child.(type) {
+	case nil:
+		return NewXDatasetSnapshot(nil).With(path, value)
+	case *XDatasetSnapshot:
+		return c.With(path, value)
+	case *snapshotCollection:
+		head, rest, sub := splitPath(path)
+		index, err := strconv.Atoi(head)
+		if err != nil || index < 0 || index >= len(c.data) {
+			return nil, errors.New("Error: the entry " + head + " of the path is not an index of the collection")
+		}
+		var entry interface{}
+		if sub {
+			if entry, err = snapshotWith(c.data[index], rest, value); err != nil {
+				return nil, err
+			}
+		} else {
+			entry = freezeValue(value)
+		}
+		ds, ok := entry.(XDatasetDef)
+		if !ok {
+			return nil, errors.New("Error: the entry of a collection must be a dataset")
+		}
+		data := append([]XDatasetDef{}, c.data...)
+		data[index] = ds
+		return &snapshotCollection{data: data}, nil
+	}
+	return nil, errors.New("Error: the value of the path is not a dataset or a collection")
+}
+
+// Without will build a new version of the snapshot without the value at the path key "a>b>c". The last entry may be the index of a collection.
+// Only the datasets and collections on the path are copied. If the key does not exist, the same snapshot is returned
+func (d *XDatasetSnapshot) Without(key string) *XDatasetSnapshot {
+	head, rest, sub := splitPath(key)
+	child, ok := (*d.data)[head]
+	if !ok {
+		return d
+	}
+	data := make(XDataset, len(*d.data))
+	for k, v := range *d.data {
+		data[k] = v
+	}
+	if !sub {
+		delete(data, head)
+		return &XDatasetSnapshot{data: &data}
+	}
+	switch child.(type) {
+	case *XDatasetSnapshot, *snapshotCollection:
+	default:
+		return d
+	}
+	newchild := snapshotWithout(child, rest)
+	if newchild == child {
+		return d
+	}
+	data[head] = newchild
+	return &XDatasetSnapshot{data: &data}
+}
+
+// snapshotWithout will build the new version of the frozen child without the value at the path, or return the same child if nothing is removed
+func snapshotWithout(child interface{}, path string) interface{} {
+	switch c := child.(type) {
+	case *XDatasetSnapshot:
+		return c.Without(path)
+	case *snapshotCollection:
+		head, rest, sub := splitPath(path)
+		index, err := strconv.Atoi(head)
+		if err != nil || index < 0 || index >= len(c.data) {
+			return child
+		}
+		data := append([]XDatasetDef{}, c.data...)
+		if !sub {
+			data = append(data[:index], data[index+1:]...)
+			return &snapshotCollection{data: data}
+		}
+		entry := snapshotWithout(c.data[index], rest)
+		if entry == interface{}(c.data[index]) {
+			return child
+		}
+		data[index] = entry.(XDatasetDef)
+		return &snapshotCollection{data: data}
+	}
+	return child
+}
+
+// Mutable will build a mutable deep copy of the snapshot, with XDataset and XDatasetCollection for the nested values
+func (d *XDatasetSnapshot) Mutable() *XDataset {
+	data := &XDataset{}
+	for key, value := range *d.data {
+		(*data)[key] = thawValue(value)
+	}
+	return data
+}
+
+// MarshalJSON will encode the snapshot as a JSON object
+func (d *XDatasetSnapshot) MarshalJSON() ([]byte, error) {
+	return d.data.MarshalJSON()
+}
+
+// String will transform the XDatasetSnapshot into a readable string for humans
+func (d *XDatasetSnapshot) String() string {
+	return "xcore.XDatasetSnapshot" + strings.TrimPrefix(d.data.String(), "xcore.XDataset")
+}
+
+// GoString will transform the XDatasetSnapshot into a readable string for humans
+func (d *XDatasetSnapshot) GoString() string {
+	return d.String()
+}
+
+// Set does nothing, the snapshot is immutable: use With to build a new version
+func (d *XDatasetSnapshot) Set(key string, data interface{}) {
+}
+
+// Get will read the value of the key variable. The key may be a path "a>b>c"
+func (d *XDatasetSnapshot) Get(key string) (interface{}, bool) {
+	return d.data.Get(key)
+}
+
+// getKey will read the value of the literal key, without following a path (see datasetKey)
+func (d *XDatasetSnapshot) getKey(key string) (interface{}, bool) {
+	return datasetKey(d.data, key)
+}
+
+// GetDataset will read the value of the key variable as a XDatasetDef cast type
+func (d *XDatasetSnapshot) GetDataset(key string) (XDatasetDef, bool) {
+	return d.data.GetDataset(key)
+}
+
+// GetCollection will read the value of the key variable as a XDatasetCollection cast type
+func (d *XDatasetSnapshot) GetCollection(key string) (XDatasetCollectionDef, bool) {
+	return d.data.GetCollection(key)
+}
+
+// GetString will read the value of the key variable as a string cast type
+func (d *XDatasetSnapshot) GetString(key string) (string, bool) {
+	return d.data.GetString(key)
+}
+
+// GetBool will read the value of the key variable as a boolean cast type
+func (d *XDatasetSnapshot) GetBool(key string) (bool, bool) {
+	return d.data.GetBool(key)
+}
+
+// GetInt will read the value of the key variable as an integer cast type
+func (d *XDatasetSnapshot) GetInt(key string) (int, bool) {
+	return d.data.GetInt(key)
+}
+
+// GetFloat will read the value of the key variable as a float64 cast type
+func (d *XDatasetSnapshot) GetFloat(key string) (float64, bool) {
+	return d.data.GetFloat(key)
+}
+
+// GetTime will read the value of the key variable as a time cast type
+func (d *XDatasetSnapshot) GetTime(key string) (time.Time, bool) {
+	return d.data.GetTime(key)
+}
+
+// GetStringCollection will read the value of the key variable as a collection of strings cast type
+func (d *XDatasetSnapshot) GetStringCollection(key string) ([]string, bool) {
+	return d.data.GetStringCollection(key)
+}
+
+// GetBoolCollection will read the value of the key variable as a collection of bool cast type
+func (d *XDatasetSnapshot) GetBoolCollection(key string) ([]bool, bool) {
+	return d.data.GetBoolCollection(key)
+}
+
+// GetIntCollection will read the value of the key variable as a collection of int cast type
+func (d *XDatasetSnapshot) GetIntCollection(key string) ([]int, bool) {
+	return d.data.GetIntCollection(key)
+}
+
+// GetFloatCollection will read the value of the key variable as a collection of float cast type
+func (d *XDatasetSnapshot) GetFloatCollection(key string) ([]float64, bool) {
+	return d.data.GetFloatCollection(key)
+}
+
+// GetTimeCollection will read the value of the key variable as a collection of time cast type
+func (d *XDatasetSnapshot) GetTimeCollection(key string) ([]time.Time, bool) {
+	return d.data.GetTimeCollection(key)
+}
+
+// Del does nothing, the snapshot is immutable: use Without to build a new version
+func (d *XDatasetSnapshot) Del(key string) {
+}
+
+// Exists will check if the key (or path "a>b>c") exists into the snapshot
+func (d *XDatasetSnapshot) Exists(key string) bool {
+	return d.data.Exists(key)
+}
+
+// Keys will return the sorted list of the keys of the snapshot
+func (d *XDatasetSnapshot) Keys() []string {
+	return d.data.Keys()
+}
+
+// Clone will return the snapshot itself: it is immutable, so it can be shared. Use Mutable to get a mutable copy
+func (d *XDatasetSnapshot) Clone() XDatasetDef {
+	return d
+}
+
+// snapshotCollection is the immutable collection of snapshots of a XDatasetSnapshot. Push and Unshift do nothing, Shift and Pop return nil
+type snapshotCollection struct {
+	data []XDatasetDef
+}
+
+// collection will return the entries as a XDatasetCollection, to share its read methods. It must not be modified
+func (c *snapshotCollection) collection() *XDatasetCollection {
+	dsc := XDatasetCollection(c.data)
+	return &dsc
+}
+
+// String will transform the collection into a readable string for humans
+func (c *snapshotCollection) String() string {
+	return "XDatasetSnapshotCollection" + strings.TrimPrefix(c.collection().String(), "XDatasetCollection")
+}
+
+// GoString will transform the collection into a readable string for humans
+func (c *snapshotCollection) GoString() string {
+	return c.String()
+}
+
+// MarshalJSON will encode the collection as a JSON array
+func (c *snapshotCollection) MarshalJSON() ([]byte, error) {
+	return c.collection().MarshalJSON()
+}
+
+// Unshift does nothing, the collection is immutable
+func (c *snapshotCollection) Unshift(data XDatasetDef) {
+}
+
+// Shift does nothing and returns nil, the collection is immutable
+func (c *snapshotCollection) Shift() XDatasetDef {
+	return nil
+}
+
+// Push does nothing, the collection is immutable
+func (c *snapshotCollection) Push(data XDatasetDef) {
+}
+
+// Pop does nothing and returns nil, the collection is immutable
+func (c *snapshotCollection) Pop() XDatasetDef {
+	return nil
+}
+
+// Count will return the quantity of entries into the collection
+func (c *snapshotCollection) Count() int {
+	return len(c.data)
+}
+
+// Get will return the snapshot at the index of the collection
+func (c *snapshotCollection) Get(index int) (XDatasetDef, bool) {
+	return c.collection().Get(index)
+}
+
+// GetData will retrieve the first available data identified by key from the collection ordered by index
+func (c *snapshotCollection) GetData(key string) (interface{}, bool) {
+	return c.collection().GetData(key)
+}
+
+// GetDataString will retrieve the first available data identified by key from the collection ordered by index and return it as a string
+func (c *snapshotCollection) GetDataString(key string) (string, bool) {
+	return c.collection().GetDataString(key)
+}
+
+// GetDataBool will retrieve the first available data identified by key from the collection ordered by index and return it as a boolean
+func (c *snapshotCollection) GetDataBool(key string) (bool, bool) {
+	return c.collection().GetDataBool(key)
+}
+
+// GetDataInt will retrieve the first available data identified by key from the collection ordered by index and return it as an integer
+func (c *snapshotCollection) GetDataInt(key string) (int, bool) {
+	return c.collection().GetDataInt(key)
+}
+
+// GetDataFloat will retrieve the first available data identified by key from the collection ordered by index and return it as a float
+func (c *snapshotCollection) GetDataFloat(key string) (float64, bool) {
+	return c.collection().GetDataFloat(key)
+}
+
+// GetDataTime will retrieve the first available data identified by key from the collection ordered by index and return it as a time
+func (c *snapshotCollection) GetDataTime(key string) (time.Time, bool) {
+	return c.collection().GetDataTime(key)
+}
+
+// GetCollection will retrieve a collection from the collection
+func (c *snapshotCollection) GetCollection(key string) (XDatasetCollectionDef, bool) {
+	return c.collection().GetCollection(key)
+}
+
+// Clone will return the collection itself: it is immutable, so it can be shared
+func (c *snapshotCollection) Clone() XDatasetCollectionDef {
+	return c
+}
+
+// XDatasetAtomic holds the current version of an immutable XDatasetSnapshot, swapped atomically by the writers.
+// The readers get the current snapshot with Load without any lock, and always see a consistent version of the whole data.
+// The writers are serialized: each one builds a new version from the current one (with structural sharing) and swaps it.
+// The zero value is an empty dataset ready to use
+type XDatasetAtomic struct {
+	mutex sync.Mutex
+	value atomic.Value
+}
+
+// NewXDatasetAtomic will create a XDatasetAtomic with an immutable snapshot of the dataset
+func NewXDatasetAtomic(ds XDatasetDef) *XDatasetAtomic {
+	a := &XDatasetAtomic{}
+	a.value.Store(NewXDatasetSnapshot(ds))
+	return a
+}
+
+// Load will return the current snapshot, without lock
+func (a *XDatasetAtomic) Load() *XDatasetSnapshot {
+	if s, ok := a.value.Load().(*XDatasetSnapshot); ok {
+		return s
+	}
+	return NewXDatasetSnapshot(nil)
+}
+
+// Store will replace the current snapshot with an immutable snapshot of the dataset
+func (a *XDatasetAtomic) Store(ds XDatasetDef) {
+	s := NewXDatasetSnapshot(ds)
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.value.Store(s)
+}
+
+// Update will call the function with the current snapshot and swap it with the returned new version.
+// The writers are serialized, so no update is lost. If the function returns an error, the snapshot is not changed
+func (a *XDatasetAtomic) Update(fn func(*XDatasetSnapshot) (*XDatasetSnapshot, error)) error {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	s, err := fn(a.Load())
+	if err != nil {
+		return err
+	}
+	if s == nil {
+		s = NewXDatasetSnapshot(nil)
+	}
+	a.value.Store(s)
+	return nil
+}
+
+// Set will swap the snapshot with a new version with the value at the path key (see XDatasetSnapshot.With)
+func (a *XDatasetAtomic) Set(key string, value interface{}) error {
+	return a.Update(func(s *XDatasetSnapshot) (*XDatasetSnapshot, error) {
+		return s.With(key, value)
+	})
+}
+
+// Del will swap the snapshot with a new version without the value at the path key (see XDatasetSnapshot.Without)
+func (a *XDatasetAtomic) Del(key string) {
+	a.Update(func(s *XDatasetSnapshot) (*XDatasetSnapshot, error) {
+		return s.Without(key), nil
+	})
+}
@@ -0,0 +1,182 @@
+package xcore
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestXDatasetSnapshot(t *testing.T) {
+	data := &XDataset{
+		"name":    "config",
+		"db":      &XDataset{"host": "localhost", "port": 5432},
+		"servers": &XDatasetCollection{&XDataset{"ip": "10.0.0.1"}, &XDataset{"ip": "10.0.0.2"}},
+	}
+	s1 := NewXDatasetSnapshot(data)
+	data.Set("name", "changed")
+	data.SetPath("db>host", "remote", false)
+	if s1.String() != "xcore.XDatasetSnapshot{db:xcore.XDatasetSnapshot{host:localhost port:5432} name:config servers:XDatasetSnapshotCollection[0:xcore.XDatasetSnapshot{ip:10.0.0.1} 1:xcore.XDatasetSnapshot{ip:10.0.0.2} ]}" {
+		t.Errorf("Error building the snapshot: %v", s1)
+		return
+	}
+
+	s1.Set("name", "ignored")
+	s1.Del("db")
+	if ip, _ := s1.GetString("servers>1>ip"); ip != "10.0.0.2" || !s1.Exists("db>port") {
+		t.Errorf("Error reading the snapshot: %v", s1)
+	}
+	db, _ := s1.GetDataset("db")
+	db.Set("host", "ignored")
+	servers, _ := s1.GetCollection("servers")
+	servers.Push(&XDataset{})
+	if host, _ := s1.GetString("db>host"); host != "localhost" || servers.Count() != 2 || servers.Pop() != nil {
+		t.Error("Error: the nested values of the snapshot must be immutable")
+	}
+
+	s2, err := s1.With("db>host", "remote")
+	if err != nil {
+		t.Errorf("Error in With: %v", err)
+		return
+	}
+	if host, _ := s2.GetString("db>host"); host != "remote" {
+		t.Errorf("Error in With: %v", s2)
+	}
+	if host, _ := s1.GetString("db>host"); host != "localhost" {
+		t.Errorf("Error: With modified the original snapshot: %v", s1)
+	}
+	servers1, _ := s1.Get("servers")
+	servers2, _ := s2.Get("servers")
+	if servers1 != servers2 {
+		t.Error("Error: the values out of the path must be shared between the versions")
+	}
+	s3, _ := s2.With("servers>0>ip", "10.0.0.9")
+	if ip, _ := s3.GetString("servers>0>ip"); ip != "10.0.0.9" {
+		t.Errorf("Error in With into a collection: %v", s3)
+	}
+	s3, _ = s3.With("cache>ttl>seconds", 60)
+	if ttl, _ := s3.GetInt("cache>ttl>seconds"); ttl != 60 {
+		t.Errorf("Error in With creating the path: %v", s3)
+	}
+	if _, err := s3.With("name>sub", 1); err == nil {
+		t.Error("Error: With into a string should fail")
+	}
+	if _, err := s3.With("servers>5>ip", 1); err == nil {
+		t.Error("Error: With out of range of a collection should fail")
+	}
+
+	s4 := s3.Without("servers>0").Without("db>port").Without("unknown").Without("name>sub")
+	if s4.Exists("db>port") || s4.Exists("servers>1") || !s3.Exists("servers>1") || !s3.Exists("db>port") {
+		t.Errorf("Error in Without: %v", s4)
+	}
+
+	mutable := s4.Mutable()
+	mutable.SetPath("db>host", "local", false)
+	if host, _ := s4.GetString("db>host"); host != "remote" || s4.Clone() != s4 {
+		t.Errorf("Error in Mutable: %v", s4)
+	}
+	if _, ok := (*mutable)["servers"].(*XDatasetCollection); !ok {
+		t.Errorf("Error: Mutable must build XDatasetCollection: %v", mutable)
+	}
+}
+
+func TestXDatasetAtomic(t *testing.T) {
+	config := NewXDatasetAtomic(&XDataset{"version": 0, "db": &XDataset{"host": "localhost"}})
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				config.Update(func(s *XDatasetSnapshot) (*XDatasetSnapshot, error) {
+					version, _ := s.GetInt("version")
+					s, err := s.With("version", version+1)
+					if err != nil {
+						return nil, err
+					}
+					return s.With("db>host", "host"+strconv.Itoa(w))
+				})
+			}
+		}(w)
+	}
+	for r := 0; r < 8; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				s := config.Load()
+				if _, ok := s.GetString("db>host"); !ok {
+					t.Error("Error: the snapshot is not consistent")
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if version, _ := config.Load().GetInt("version"); version != 400 {
+		t.Errorf("Error: lost updates: %d", version)
+	}
+
+	config.Set("db>port", 5432)
+	config.Del("version")
+	if port, _ := config.Load().GetInt("db>port"); port != 5432 || config.Load().Exists("version") {
+		t.Errorf("Error in Set and Del: %v", config.Load())
+	}
+	var empty XDatasetAtomic
+	if empty.Load().String() != "xcore.XDatasetSnapshot{}" {
+		t.Errorf("Error in the zero XDatasetAtomic: %v", empty.Load())
+	}
+	empty.Store(&XDataset{"a": 1})
+	if a, _ := empty.Load().GetInt("a"); a != 1 {
+		t.Errorf("Error in Store: %v", empty.Load())
+	}
+}
+
+func TestXDatasetSnapshot_SlicesAndLoops(t *testing.T) {
+	tags := []string{"a", "b"}
+	data := &XDataset{"tags": tags, "list": &XDatasetCollection{&XDataset{"name": "a"}, &XDataset{"name": "b"}}}
+	s := NewXDatasetSnapshot(data)
+	tags[0] = "changed"
+	if frozen, _ := s.GetStringCollection("tags"); frozen[0] != "a" {
+		t.Errorf("Error: the slices of the snapshot must not be shared with the source: %v", frozen)
+	}
+	mutable := s.Mutable()
+	(*mutable)["tags"].([]string)[1] = "changed"
+	if frozen, _ := s.GetStringCollection("tags"); frozen[1] != "b" {
+		t.Errorf("Error: the slices of the snapshot must not be shared with the mutable copy: %v", frozen)
+	}
+
+	// the loop fields are available on the entries of an immutable collection, and the source is not modified
+	tmpl, _ := NewXTemplateFromString("@@list@@[[list]]{{.counter}}:{{name}};[[]]")
+	if result := tmpl.Execute(s); result != "1:a;2:b;" {
+		t.Errorf("Error looping over a snapshot: %s", result)
+	}
+	if result := tmpl.Execute(data); result != "1:a;2:b;" || data.Exists("list>0>.counter") {
+		t.Errorf("Error looping over a dataset: %s %v", result, data)
+	}
+}
+
+func TestXDatasetSnapshot_NilEntries(t *testing.T) {
+	s := NewXDatasetSnapshot(&XDataset{"list": &XDatasetCollection{nil, &XDataset{"name": "b"}}})
+	list, _ := s.GetCollection("list")
+	if first, ok := list.Get(0); !ok || first != nil || list.Count() != 2 {
+		t.Errorf("Error: the nil entry must stay nil: %v", list)
+	}
+	mutable := s.Mutable()
+	if first, _ := (*mutable)["list"].(*XDatasetCollection).Get(0); first != nil {
+		t.Errorf("Error: the nil entry must stay nil into the mutable copy: %v", mutable)
+	}
+	tmpl, _ := NewXTemplateFromString("@@list@@[[list]]{{.counter}}:{{name}};[[]]")
+	if result := tmpl.Execute(s); result != "1:;2:b;" {
+		t.Errorf("Error looping over a nil entry: %s", result)
+	}
+
+	// all the getters read the loop fields first
+	entry := &loopEntry{XDatasetDef: &XDataset{".counter": "entry"}, fields: XDataset{".counter": 2}}
+	f, _ := entry.GetFloat(".counter")
+	b, _ := entry.GetBool(".counter")
+	i, _ := entry.GetInt(".counter")
+	str, _ := entry.GetString(".counter")
+	if f != 2 || !b || i != 2 || str != "2" {
+		t.Errorf("Error reading the loop fields: %v %v %v %v", f, b, i, str)
+	}
+}
@@ -0,0 +1,138 @@
+package xcore
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// XDatasetSQLTimeLayouts are the layouts used to convert the text values of the date and time columns (DATE, DATETIME, TIMESTAMP...) to time.Time
+var XDatasetSQLTimeLayouts = []string{
+	"2006-01-02 15:04:05.999999999-07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999Z07:00",
+	"2006-01-02",
+}
+
+// XDatasetRows is an iterator over a *sql.Rows result set that builds a XDataset for each record, without loading the whole result set in memory.
+// The keys of the XDataset are the column names.
+//
+//	rows, err := db.Query("select id, name, birthdate from client")
+//	xrows, err := xcore.NewXDatasetRows(rows)
+//	defer xrows.Close()
+//	for xrows.Next() {
+//	  record := xrows.Dataset()
+//	}
+//	err = xrows.Err()
+type XDatasetRows struct {
+	rows    *sql.Rows
+	columns []string
+	types   []string
+	current *XDataset
+	err     error
+}
+
+// NewXDatasetRows will build the iterator of XDataset on the result set
+func NewXDatasetRows(rows *sql.Rows) (*XDatasetRows, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	types := make([]string, len(columns))
+	if columntypes, err := rows.ColumnTypes(); err == nil {
+		for i, ct := range columntypes {
+			types[i] = strings.ToUpper(ct.DatabaseTypeName())
+		}
+	}
+	return &XDatasetRows{rows: rows, columns: columns, types: types}, nil
+}
+
+// Next will read the next record of the result set. Returns false when there are no more records or in case of error (see Err)
+func (r *XDatasetRows) Next() bool {
+	r.current = nil
+	if r.err != nil || !r.rows.Next() {
+		return false
+	}
+	values := make([]interface{}, len(r.columns))
+	pointers := make([]interface{}, len(r.columns))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+	if r.err = r.rows.Scan(pointers...); r.err != nil {
+		return false
+	}
+	ds := &XDataset{}
+	for i, column := range r.columns {
+		(*ds)[column] = sqlValue(values[i], r.types[i])
+	}
+	r.current = ds
+	return true
+}
+
+// Dataset will return the XDataset of the current record
+func (r *XDatasetRows) Dataset() *XDataset {
+	return r.current
+}
+
+// Columns will return the names of the columns of the result set
+func (r *XDatasetRows) Columns() []string {
+	return r.columns
+}
+
+// Err will return the error of the iteration, if any
+func (r *XDatasetRows) Err() error {
+	if r.err != nil {
+		return r.err
+	}
+	return r.rows.Err()
+}
+
+// Close will close the result set
+func (r *XDatasetRows) Close() error {
+	return r.rows.Close()
+}
+
+// NewXDatasetCollectionFromRows will read all the records of the result set into a XDatasetCollection of XDataset, with the column names as keys.
+// The NULL values are nil, the []byte are strings, the integers are int and the date and time columns are time.Time.
+// The result set is not closed.
+func NewXDatasetCollectionFromRows(rows *sql.Rows) (*XDatasetCollection, error) {
+	xrows, err := NewXDatasetRows(rows)
+	if err != nil {
+		return nil, err
+	}
+	dsc := &XDatasetCollection{}
+	for xrows.Next() {
+		dsc.Push(xrows.Dataset())
+	}
+	if err := xrows.Err(); err != nil {
+		return nil, err
+	}
+	return dsc, nil
+}
+
+// sqlValue will convert the value scanned from the database to the XDataset types, based on the database type of the column
+func sqlValue(value interface{}, dbtype string) interface{} {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case int64:
+		return int(v)
+	case []byte:
+		return sqlText(string(v), dbtype)
+	case string:
+		return sqlText(v, dbtype)
+	}
+	return value
+}
+
+// sqlText will convert the text of date and time columns to time.Time
+func sqlText(value string, dbtype string) interface{} {
+	if strings.Contains(dbtype, "DATE") || strings.Contains(dbtype, "TIMESTAMP") {
+		for _, layout := range XDatasetSQLTimeLayouts {
+			if t, err := time.Parse(layout, value); err == nil {
+				return t
+			}
+		}
+	}
+	return value
+}
@@ -0,0 +1,129 @@
+package xcore
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+// A stub database driver that always returns the same result set
+type stubDriver struct{}
+type stubConn struct{}
+type stubStmt struct{}
+type stubRows struct {
+	pos int
+}
+
+var stubColumns = []string{"id", "name", "salary", "active", "photo", "birthdate", "created"}
+var stubTypes = []string{"INTEGER", "VARCHAR", "DECIMAL", "BOOLEAN", "BLOB", "DATE", "TIMESTAMP"}
+var stubCreated, _ = time.Parse(time.RFC3339, "2020-01-01T12:00:00Z")
+var stubData = [][]driver.Value{
+	{int64(1), []byte("Fred"), 3568.65, true, nil, []byte("1980-05-17"), stubCreated},
+	{int64(2), "Juan", nil, false, []byte{}, "1990-01-31", []byte("2021-02-03 04:05:06")},
+}
+
+func (d stubDriver) Open(name string) (driver.Conn, error) { return stubConn{}, nil }
+func (c stubConn) Prepare(query string) (driver.Stmt, error) {
+	if query == "error" {
+		return nil, errors.New("stub error")
+	}
+	return stubStmt{}, nil
+}
+func (c stubConn) Close() error              { return nil }
+func (c stubConn) Begin() (driver.Tx, error) { return nil, errors.New("no transactions") }
+func (s stubStmt) Close() error              { return nil }
+func (s stubStmt) NumInput() int             { return 0 }
+func (s stubStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return nil, errors.New("no exec")
+}
+func (s stubStmt) Query(args []driver.Value) (driver.Rows, error) { return &stubRows{}, nil }
+func (r *stubRows) Columns() []string                             { return stubColumns }
+func (r *stubRows) Close() error                                  { return nil }
+func (r *stubRows) ColumnTypeDatabaseTypeName(index int) string   { return stubTypes[index] }
+func (r *stubRows) Next(dest []driver.Value) error {
+	if r.pos >= len(stubData) {
+		return io.EOF
+	}
+	copy(dest, stubData[r.pos])
+	r.pos++
+	return nil
+}
+
+func init() {
+	sql.Register("xcorestub", stubDriver{})
+}
+
+func TestNewXDatasetCollectionFromRows(t *testing.T) {
+	db, err := sql.Open("xcorestub", "")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer db.Close()
+
+	rows, err := db.Query("select * from client")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer rows.Close()
+	dsc, err := NewXDatasetCollectionFromRows(rows)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if dsc.Count() != 2 {
+		t.Errorf("Error reading the rows: %v", dsc)
+		return
+	}
+	str := dsc.String()
+	if str != "XDatasetCollection[0:xcore.XDataset{active:true birthdate:1980-05-17 00:00:00 +0000 UTC created:2020-01-01 12:00:00 +0000 UTC id:1 name:Fred photo:<nil> salary:3568.65} 1:xcore.XDataset{active:false birthdate:1990-01-31 00:00:00 +0000 UTC created:2021-02-03 04:05:06 +0000 UTC id:2 name:Juan photo: salary:<nil>} ]" {
+		t.Errorf("Error converting the rows: %s", str)
+	}
+	ds, _ := dsc.Get(0)
+	if v, ok := ds.Get("id"); !ok || v != 1 {
+		t.Errorf("The integers should be int: %#v", v)
+	}
+	if v, ok := ds.Get("name"); !ok || v != "Fred" {
+		t.Errorf("The []byte should be string: %#v", v)
+	}
+	if v, ok := ds.Get("photo"); !ok || v != nil {
+		t.Errorf("The NULL should be nil: %#v", v)
+	}
+}
+
+func TestXDatasetRows(t *testing.T) {
+	db, _ := sql.Open("xcorestub", "")
+	defer db.Close()
+
+	rows, err := db.Query("select * from client")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	xrows, err := NewXDatasetRows(rows)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer xrows.Close()
+	names := ""
+	for xrows.Next() {
+		name, _ := xrows.Dataset().GetString("name")
+		names += name + ";"
+	}
+	if err := xrows.Err(); err != nil {
+		t.Error(err)
+	}
+	if names != "Fred;Juan;" || xrows.Dataset() != nil || len(xrows.Columns()) != len(stubColumns) {
+		t.Errorf("Error iterating the rows: %s", names)
+	}
+
+	_, err = db.Query("error")
+	if err == nil {
+		t.Error("The stub query should fail")
+	}
+}
@@ -0,0 +1,438 @@
+package xcore
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// structField is a field of a struct with its key into the XDataset
+type structField struct {
+	index     []int
+	key       string
+	omitempty bool
+}
+
+// structFields will list the exported fields of the struct type with their keys.
+// The key is the name in the tag xcore:"name,omitempty", or the lowercase name of the field. The tag xcore:"-" ignores the field.
+// The fields of the embedded structs are promoted as in encoding/json.
+func structFields(t reflect.Type) []structField {
+	fields := []structField{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("xcore")
+		if tag == "-" {
+			continue
+		}
+		name, options := tag, ""
+		if pos := strings.Index(tag, ","); pos >= 0 {
+			name, options = tag[:pos], tag[pos+1:]
+		}
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct && ft != timeType {
+				for _, sf := range structFields(ft) {
+					sf.index = append([]int{i}, sf.index...)
+					fields = append(fields, sf)
+				}
+				continue
+			}
+		}
+		if f.PkgPath != "" { // not exported
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		fields = append(fields, structField{index: []int{i}, key: name, omitempty: options == "omitempty"})
+	}
+	return fields
+}
+
+// NewXDatasetFromStruct will build a XDataset from a struct (or a pointer to a struct).
+// The keys are the names of the tags xcore:"name,omitempty", or the lowercase names of the fields.
+// The nested structs become XDataset, the slices of structs XDatasetCollection and the time.Time are preserved.
+// Returns an error if a pointer refers to a struct that contains it (a cycle).
+func NewXDatasetFromStruct(data interface{}) (*XDataset, error) {
+	rv := reflect.ValueOf(data)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil, errors.New("Error: cannot build a XDataset from a nil pointer")
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("Error: cannot build a XDataset from a %s", rv.Kind())
+	}
+	return structToXDataset(rv, map[structPointer]bool{})
+}
+
+// structPointer is a pointer being converted, to detect the cycles. The type distinguishes a struct from its first field
+type structPointer struct {
+	ptr uintptr
+	typ reflect.Type
+}
+
+// structToXDataset will build the XDataset from the struct value. visiting contains the pointers being converted
+func structToXDataset(rv reflect.Value, visiting map[structPointer]bool) (*XDataset, error) {
+	ds := &XDataset{}
+	for _, f := range structFields(rv.Type()) {
+		fv, ok := fieldByIndex(rv, f.index)
+		if !ok {
+			continue
+		}
+		if f.omitempty && fv.IsZero() {
+			continue
+		}
+		value, err := structValue(fv, visiting)
+		if err != nil {
+			return nil, err
+		}
+		(*ds)[f.key] = value
+	}
+	return ds, nil
+}
+
+// fieldByIndex will get the field of the struct, false if it is into a nil embedded pointer
+func fieldByIndex(rv reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 {
+			if rv.Kind() == reflect.Ptr {
+				if rv.IsNil() {
+					return reflect.Value{}, false
+				}
+				rv = rv.Elem()
+			}
+		}
+		rv = rv.Field(x)
+	}
+	return rv, true
+}
+
+// structValue will convert the value of a field to the XDataset types. visiting contains the pointers being converted
+func structValue(rv reflect.Value, visiting map[structPointer]bool) (interface{}, error) {
+	if !rv.IsValid() {
+		return nil, nil
+	}
+	if rv.CanInterface() {
+		switch v := rv.Interface().(type) {
+		case time.Time, XDatasetDef, XDatasetCollectionDef:
+			return v, nil
+		}
+	}
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if rv.IsNil() {
+			return nil, nil
+		}
+		if rv.Kind() == reflect.Ptr {
+			p := structPointer{ptr: rv.Pointer(), typ: rv.Type()}
+			if visiting[p] {
+				return nil, fmt.Errorf("Error: cannot build a XDataset from a cycle of pointers (%s)", rv.Type())
+			}
+			visiting[p] = true
+			defer delete(visiting, p)
+		}
+		return structValue(rv.Elem(), visiting)
+	case reflect.Struct:
+		return structToXDataset(rv, visiting)
+	case reflect.Slice, reflect.Array:
+		et := rv.Type().Elem()
+		for et.Kind() == reflect.Ptr {
+			et = et.Elem()
+		}
+		if et.Kind() == reflect.Struct && et != timeType {
+			dsc := &XDatasetCollection{}
+			for i := 0; i < rv.Len(); i++ {
+				value, err := structValue(rv.Index(i), visiting)
+				if err != nil {
+					return nil, err
+				}
+				if ds, ok := value.(XDatasetDef); ok {
+					dsc.Push(ds)
+				}
+			}
+			return dsc, nil
+		}
+	case reflect.Map:
+		if rv.Type().Key().Kind() == reflect.String {
+			ds := &XDataset{}
+			iter := rv.MapRange()
+			for iter.Next() {
+				value, err := structValue(iter.Value(), visiting)
+				if err != nil {
+					return nil, err
+				}
+				(*ds)[iter.Key().String()] = value
+			}
+			return ds, nil
+		}
+	}
+	return rv.Interface(), nil
+}
+
+// Bind will fill the struct pointed by target with the values of the XDataset.
+// The keys are the names of the tags xcore:"name,omitempty", or the lowercase names of the fields.
+// The values are converted to the type of the fields when possible (for instance the strings of a form to int, float, bool or time.Time RFC 3339).
+// Returns an error if a value cannot be converted, or if a number does not fit into the field (out of range, or with decimals for an integer field).
+// The keys that are not into the XDataset do not modify the fields.
+// The fields of a nil pointer to an unexported embedded struct are ignored, since it cannot be allocated.
+func (d *XDataset) Bind(target interface{}) error {
+	return bindXDataset(d, target)
+}
+
+// Bind will fill the struct pointed by target with the values of the XDatasetTS (see XDataset.Bind)
+func (ds *XDatasetTS) Bind(target interface{}) error {
+	ds.mutex.RLock()
+	defer ds.mutex.RUnlock()
+	return bindXDataset(ds.data, target)
+}
+
+// bindXDataset will fill the struct pointed by target with the values of the dataset
+func bindXDataset(ds XDatasetDef, target interface{}) error {
+	rv := reflect.ValueOf(target)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return errors.New("Error: the target of Bind must be a non nil pointer to a struct")
+	}
+	return bindStruct(ds, rv.Elem())
+}
+
+// bindStruct will fill the struct value with the values of the dataset
+func bindStruct(ds XDatasetDef, rv reflect.Value) error {
+fields:
+	for _, f := range structFields(rv.Type()) {
+		value, ok := ds.Get(f.key)
+		if !ok {
+			continue
+		}
+		fv := rv
+		for i, x := range f.index {
+			if i > 0 && fv.Kind() == reflect.Ptr {
+				if fv.IsNil() {
+					// a nil pointer to an unexported embedded struct cannot be allocated: its fields are ignored (as in encoding/json)
+					if !fv.CanSet() {
+						continue fields
+					}
+					fv.Set(reflect.New(fv.Type().Elem()))
+				}
+				fv = fv.Elem()
+			}
+			fv = fv.Field(x)
+		}
+		if err := bindValue(fv, value); err != nil {
+			return fmt.Errorf("Error: cannot bind the key %s: %v", f.key, err)
+		}
+	}
+	return nil
+}
+
+// bindValue will set the value into the field, converting it to the type of the field
+func bindValue(fv reflect.Value, value interface{}) error {
+	if value == nil {
+		fv.Set(reflect.Zero(fv.Type()))
+		return nil
+	}
+	rv := reflect.ValueOf(value)
+	if rv.Type().AssignableTo(fv.Type()) {
+		fv.Set(rv)
+		return nil
+	}
+	switch fv.Kind() {
+	case reflect.Ptr:
+		elem := reflect.New(fv.Type().Elem())
+		if err := bindValue(elem.Elem(), value); err != nil {
+			return err
+		}
+		fv.Set(elem)
+		return nil
+	case reflect.Struct:
+		if fv.Type() == timeType {
+			if str, ok := value.(string); ok {
+				t, err := time.Parse(time.RFC3339, str)
+				if err != nil {
+					return err
+				}
+				fv.Set(reflect.ValueOf(t))
+				return nil
+			}
+		} else if ds, ok := value.(XDatasetDef); ok {
+			return bindStruct(ds, fv)
+		}
+	case reflect.String:
+		fv.SetString(fmt.Sprint(value))
+		return nil
+	case reflect.Bool:
+		switch v := value.(type) {
+		case string:
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return err
+			}
+			fv.SetBool(b)
+			return nil
+		}
+		if isNumberKind(rv.Kind()) {
+			fv.SetBool(rv.Convert(reflect.TypeOf(0.0)).Float() != 0)
+			return nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if str, ok := value.(string); ok {
+			i, err := strconv.ParseInt(strings.TrimSpace(str), 10, fv.Type().Bits())
+			if err != nil {
+				return err
+			}
+			fv.SetInt(i)
+			return nil
+		}
+		if b, ok := value.(bool); ok {
+			if b {
+				fv.SetInt(1)
+			} else {
+				fv.SetInt(0)
+			}
+			return nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if str, ok := value.(string); ok {
+			i, err := strconv.ParseUint(strings.TrimSpace(str), 10, fv.Type().Bits())
+			if err != nil {
+				return err
+			}
+			fv.SetUint(i)
+			return nil
+		}
+	case reflect.Float32, reflect.Float64:
+		if str, ok := value.(string); ok {
+			f, err := strconv.ParseFloat(strings.TrimSpace(str), fv.Type().Bits())
+			if err != nil {
+				return err
+			}
+			fv.SetFloat(f)
+			return nil
+		}
+	case reflect.Slice:
+		if dsc, ok := value.(XDatasetCollectionDef); ok {
+			slice := reflect.MakeSlice(fv.Type(), dsc.Count(), dsc.Count())
+			for i := 0; i < dsc.Count(); i++ {
+				ds, _ := dsc.Get(i)
+				if err := bindValue(slice.Index(i), ds); err != nil {
+					return err
+				}
+			}
+			fv.Set(slice)
+			return nil
+		}
+		if rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array {
+			slice := reflect.MakeSlice(fv.Type(), rv.Len(), rv.Len())
+			for i := 0; i < rv.Len(); i++ {
+				if err := bindValue(slice.Index(i), rv.Index(i).Interface()); err != nil {
+					return err
+				}
+			}
+			fv.Set(slice)
+			return nil
+		}
+	case reflect.Map:
+		if ds, ok := value.(XDatasetDef); ok && fv.Type().Key().Kind() == reflect.String {
+			keys, _ := datasetKeys(ds)
+			m := reflect.MakeMapWithSize(fv.Type(), len(keys))
+			for _, key := range keys {
+				v, _ := datasetKey(ds, key)
+				elem := reflect.New(fv.Type().Elem()).Elem()
+				if err := bindValue(elem, v); err != nil {
+					return err
+				}
+				m.SetMapIndex(reflect.ValueOf(key).Convert(fv.Type().Key()), elem)
+			}
+			fv.Set(m)
+			return nil
+		}
+	}
+	if isNumberKind(fv.Kind()) && isNumberKind(rv.Kind()) {
+		return bindNumber(fv, rv)
+	}
+	return fmt.Errorf("the value of type %T cannot be converted to %s", value, fv.Type())
+}
+
+// bindNumber will set the number into the numeric field. Returns an error if the number is out of the range of the field, or has decimals for an integer field
+func bindNumber(fv reflect.Value, rv reflect.Value) error {
+	var f float64
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i := rv.Int()
+		switch fv.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if fv.OverflowInt(i) {
+				return fmt.Errorf("the value %d overflows %s", i, fv.Type())
+			}
+			fv.SetInt(i)
+			return nil
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			if i < 0 || fv.OverflowUint(uint64(i)) {
+				return fmt.Errorf("the value %d overflows %s", i, fv.Type())
+			}
+			fv.SetUint(uint64(i))
+			return nil
+		}
+		f = float64(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u := rv.Uint()
+		switch fv.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if u > math.MaxInt64 || fv.OverflowInt(int64(u)) {
+				return fmt.Errorf("the value %d overflows %s", u, fv.Type())
+			}
+			fv.SetInt(int64(u))
+			return nil
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			if fv.OverflowUint(u) {
+				return fmt.Errorf("the value %d overflows %s", u, fv.Type())
+			}
+			fv.SetUint(u)
+			return nil
+		}
+		f = float64(u)
+	default:
+		f = rv.Float()
+	}
+	switch fv.Kind() {
+	case reflect.Float32, reflect.Float64:
+		if fv.OverflowFloat(f) {
+			return fmt.Errorf("the value %v overflows %s", f, fv.Type())
+		}
+		fv.SetFloat(f)
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if f != math.Trunc(f) || f < math.MinInt64 || f >= math.MaxInt64 || fv.OverflowInt(int64(f)) {
+			return fmt.Errorf("the value %v cannot be converted to %s without loss", f, fv.Type())
+		}
+		fv.SetInt(int64(f))
+		return nil
+	}
+	// unsigned field
+	if f != math.Trunc(f) || f < 0 || f >= math.MaxUint64 || fv.OverflowUint(uint64(f)) {
+		return fmt.Errorf("the value %v cannot be converted to %s without loss", f, fv.Type())
+	}
+	fv.SetUint(uint64(f))
+	return nil
+}
+
+// isNumberKind will check if the kind is an integer or a float
+func isNumberKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
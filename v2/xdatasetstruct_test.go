@@ -0,0 +1,167 @@
+package xcore
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type testAddress struct {
+	City string `xcore:"city"`
+	Zip  string `xcore:"zip,omitempty"`
+}
+
+type testBase struct {
+	ID int `xcore:"id"`
+}
+
+type testHobby struct {
+	Name  string
+	Sport bool
+}
+
+type testClient struct {
+	testBase
+	Name     string            `xcore:"clientname"`
+	Age      int               `xcore:"age"`
+	Salary   float64           `xcore:"salary,omitempty"`
+	Hired    time.Time         `xcore:"hired"`
+	Address  testAddress       `xcore:"address"`
+	Previous *testAddress      `xcore:"previous,omitempty"`
+	Hobbies  []testHobby       `xcore:"hobbies"`
+	Tags     []string          `xcore:"tags"`
+	Extra    map[string]string `xcore:"extra"`
+	Password string            `xcore:"-"`
+	internal int
+}
+
+func TestNewXDatasetFromStruct(t *testing.T) {
+	hired, _ := time.Parse(time.RFC3339, "2020-01-01T12:00:00Z")
+	client := &testClient{
+		testBase: testBase{ID: 7},
+		Name:     "Fred",
+		Age:      42,
+		Hired:    hired,
+		Address:  testAddress{City: "Mexico"},
+		Hobbies:  []testHobby{{Name: "Football", Sport: true}, {Name: "Videogames"}},
+		Tags:     []string{"a", "b"},
+		Extra:    map[string]string{"color": "blue"},
+		Password: "secret",
+	}
+	ds, err := NewXDatasetFromStruct(client)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	str := ds.String()
+	if str != "xcore.XDataset{address:xcore.XDataset{city:Mexico} age:42 clientname:Fred extra:xcore.XDataset{color:blue} hired:2020-01-01 12:00:00 +0000 UTC hobbies:XDatasetCollection[0:xcore.XDataset{name:Football sport:true} 1:xcore.XDataset{name:Videogames sport:false} ] id:7 tags:[a b]}" {
+		t.Errorf("Error building the XDataset from the struct: %s", str)
+		return
+	}
+	if v, _ := ds.GetTime("hired"); !v.Equal(hired) {
+		t.Errorf("The time is not preserved: %v", v)
+	}
+
+	// and back to the struct
+	client2 := &testClient{}
+	err = ds.Bind(client2)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	client.Password = ""
+	if !reflect.DeepEqual(client, client2) {
+		t.Errorf("Error binding the XDataset into the struct: %#v", client2)
+	}
+
+	_, err = NewXDatasetFromStruct("not a struct")
+	if err == nil {
+		t.Error("A string should not be converted to a XDataset")
+	}
+}
+
+func TestXDataset_BindForm(t *testing.T) {
+	// data from a form are strings
+	form := &XDataset{
+		"id":         "12",
+		"clientname": "Fred",
+		"age":        "42",
+		"salary":     "3568.65",
+		"hired":      "2020-01-01T12:00:00Z",
+		"address":    &XDataset{"city": "Mexico", "zip": 1000},
+		"previous":   &XDataset{"city": "Paris"},
+		"hobbies":    &XDatasetCollection{&XDataset{"name": "Football", "sport": "true"}},
+		"tags":       []interface{}{"a", 2},
+	}
+	client := &testClient{}
+	err := NewXDatasetTS(form).Bind(client)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if client.ID != 12 || client.Age != 42 || client.Salary != 3568.65 || client.Hired.Year() != 2020 || client.Address.Zip != "1000" ||
+		client.Previous == nil || client.Previous.City != "Paris" || len(client.Hobbies) != 1 || !client.Hobbies[0].Sport || !reflect.DeepEqual(client.Tags, []string{"a", "2"}) {
+		t.Errorf("Error binding the form into the struct: %#v", client)
+	}
+
+	form.Set("age", "not a number")
+	err = form.Bind(client)
+	if err == nil {
+		t.Error("A wrong number should not be binded")
+	}
+	err = form.Bind(*client)
+	if err == nil {
+		t.Error("The target must be a pointer")
+	}
+}
+
+type testInner struct {
+	X int
+}
+
+type testOuter struct {
+	*testInner
+	Y int
+}
+
+func TestXDataset_BindUnexportedEmbedded(t *testing.T) {
+	// a nil pointer to an unexported embedded struct cannot be allocated: its fields are ignored
+	outer := &testOuter{}
+	if err := (&XDataset{"x": 3, "y": 4}).Bind(outer); err != nil || outer.testInner != nil || outer.Y != 4 {
+		t.Errorf("Error binding the unexported embedded pointer: %v %+v", err, outer)
+	}
+	// an allocated one is filled
+	outer = &testOuter{testInner: &testInner{}}
+	if err := (&XDataset{"x": 3, "y": 4}).Bind(outer); err != nil || outer.X != 3 || outer.Y != 4 {
+		t.Errorf("Error binding the allocated unexported embedded pointer: %v %+v", err, outer)
+	}
+}
+
+type testNode struct {
+	Name   string
+	Parent *testNode
+	Small  int8
+	Count  uint
+}
+
+func TestXDataset_StructCyclesAndRanges(t *testing.T) {
+	root := &testNode{Name: "root"}
+	child := &testNode{Name: "child", Parent: root}
+	if ds, err := NewXDatasetFromStruct(child); err != nil || !ds.Exists("parent>name") {
+		t.Errorf("Error building a struct with a parent: %v %v", err, ds)
+	}
+	root.Parent = child
+	if _, err := NewXDatasetFromStruct(child); err == nil {
+		t.Error("Error: a cycle of pointers should fail")
+	}
+
+	node := &testNode{}
+	if err := (&XDataset{"small": 100, "count": 4.0}).Bind(node); err != nil || node.Small != 100 || node.Count != 4 {
+		t.Errorf("Error binding the numbers: %v %+v", err, node)
+	}
+	for _, ds := range []*XDataset{{"small": 1000}, {"small": 3.9}, {"count": -1}, {"count": 1.5}} {
+		if err := ds.Bind(node); err == nil {
+			t.Errorf("Error: binding %v should fail", ds)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package xcore
+
+import (
+	"errors"
+	"reflect"
+)
+
+// compareSetValue will check if the current value is the old value of a CompareAndSet. A nil old value matches a missing key too
+func compareSetValue(current interface{}, exists bool, old interface{}) bool {
+	if !exists {
+		return old == nil
+	}
+	return reflect.DeepEqual(current, old)
+}
+
+// incrementValue will add the delta to the current integer value. A missing or nil value is 0
+func incrementValue(current interface{}, exists bool, delta int) (int, error) {
+	if !exists || current == nil {
+		return delta, nil
+	}
+	if !isIntegerKind(reflect.ValueOf(current).Kind()) {
+		return 0, errors.New("Error: the value to increment is not an integer")
+	}
+	value, _ := convertInt(current)
+	return value + delta, nil
+}
+
+// Update will call the function with the encapsulated dataset under the write lock, so all the changes of the function are atomic.
+// The function must use the dataset it receives, not the XDatasetTS (that would be a deadlock). Returns the error of the function.
+// The nested XDatasetTS have their own lock: the changes are atomic for the accesses through this XDatasetTS only
+func (ds *XDatasetTS) Update(fn func(d XDatasetDef) error) error {
+	ds.mutex.Lock()
+	defer ds.mutex.Unlock()
+	err := fn(ds.data)
+	guardNested(ds.data)
+	return err
+}
+
+// View will call the function with the encapsulated dataset under the read lock, so all the reads of the function see the same data.
+// The function must not modify the dataset. Returns the error of the function
+func (ds *XDatasetTS) View(fn func(d XDatasetDef) error) error {
+	ds.mutex.RLock()
+	defer ds.mutex.RUnlock()
+	return fn(ds.data)
+}
+
+// CompareAndSet will set the value at the path key only if the current value is the old value (compared deeply).
+// A nil old value means the key must not exist (or be nil). Returns true if the value has been set
+func (ds *XDatasetTS) CompareAndSet(key string, old interface{}, value interface{}) bool {
+	ds.mutex.Lock()
+	defer ds.mutex.Unlock()
+	current, ok := ds.data.Get(key)
+	if !compareSetValue(current, ok, old) {
+		return false
+	}
+	return ds.setPath(key, value, false) == nil
+}
+
+// Increment will add the delta to the integer value at the path key atomically, and return the new value. A missing key starts at 0.
+// Returns an error if the value is not an integer
+func (ds *XDatasetTS) Increment(key string, delta int) (int, error) {
+	ds.mutex.Lock()
+	defer ds.mutex.Unlock()
+	current, ok := ds.data.Get(key)
+	value, err := incrementValue(current, ok, delta)
+	if err != nil {
+		return 0, err
+	}
+	if err := ds.setPath(key, value, false); err != nil {
+		return 0, err
+	}
+	return value, nil
+}
+
+// Update will call the function with the entries of the collection under the write lock, so all the changes of the function are atomic.
+// The function must use the collection it receives, not the XDatasetCollectionTS (that would be a deadlock). Returns the error of the function
+func (dc *XDatasetCollectionTS) Update(fn func(dc XDatasetCollectionDef) error) error {
+	dc.mutex.Lock()
+	defer dc.mutex.Unlock()
+	data := XDatasetCollection(dc.data)
+	err := fn(&data)
+	dc.data = make([]XDatasetDef, 0, len(data))
+	for _, ds := range data {
+		dc.data = append(dc.data, guardOwnedDataset(ds))
+	}
+	return err
+}
+
+// View will call the function with the entries of the collection under the read lock, so all the reads of the function see the same data.
+// The function must not modify the collection. Returns the error of the function
+func (dc *XDatasetCollectionTS) View(fn func(dc XDatasetCollectionDef) error) error {
+	dc.mutex.RLock()
+	defer dc.mutex.RUnlock()
+	data := XDatasetCollection(dc.data)
+	return fn(&data)
+}
+
+// CompareAndSet will set the value at the path "index>a>b" only if the current value is the old value (compared deeply).
+// A nil old value means the key must not exist (or be nil). Returns true if the value has been set
+func (dc *XDatasetCollectionTS) CompareAndSet(path string, old interface{}, value interface{}) bool {
+	dc.mutex.Lock()
+	defer dc.mutex.Unlock()
+	current, ok := sliceGet(dc.data, path)
+	if !compareSetValue(current, ok, old) {
+		return false
+	}
+	return dc.setPath(path, value, false) == nil
+}
+
+// Increment will add the delta to the integer value at the path "index>a>b" atomically, and return the new value. A missing key starts at 0.
+// Returns an error if the value is not an integer
+func (dc *XDatasetCollectionTS) Increment(path string, delta int) (int, error) {
+	dc.mutex.Lock()
+	defer dc.mutex.Unlock()
+	current, ok := sliceGet(dc.data, path)
+	value, err := incrementValue(current, ok, delta)
+	if err != nil {
+		return 0, err
+	}
+	if err := dc.setPath(path, value, false); err != nil {
+		return 0, err
+	}
+	return value, nil
+}
@@ -0,0 +1,112 @@
+package xcore
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestXDatasetTS_Transaction(t *testing.T) {
+	ds := NewXDatasetTS(&XDataset{"counter": 0, "cas": 0, "name": "a"})
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				ds.Increment("counter", 1)
+				ds.Update(func(d XDatasetDef) error {
+					total, _ := d.GetInt("total")
+					d.Set("total", total+2)
+					d.Set("last", &XDataset{"total": total + 2})
+					return nil
+				})
+				for {
+					old, _ := ds.GetInt("cas")
+					if ds.CompareAndSet("cas", old, old+1) {
+						break
+					}
+				}
+				ds.View(func(d XDatasetDef) error {
+					total, _ := d.GetInt("total")
+					last, _ := d.GetInt("last>total")
+					if total != last {
+						t.Errorf("Error: View is not consistent: %d %d", total, last)
+					}
+					return nil
+				})
+			}
+		}()
+	}
+	wg.Wait()
+	counter, _ := ds.GetInt("counter")
+	total, _ := ds.GetInt("total")
+	cas, _ := ds.GetInt("cas")
+	if counter != 800 || total != 1600 || cas != 800 {
+		t.Errorf("Error: lost updates %d %d %d", counter, total, cas)
+	}
+	if last, _ := ds.GetDataset("last"); fmt.Sprintf("%T", last) != "*xcore.XDatasetTS" {
+		t.Error("Error: the dataset set by Update is not encapsulated")
+	}
+
+	if ds.CompareAndSet("name", "b", "c") {
+		t.Error("Error: CompareAndSet with a wrong old value should fail")
+	}
+	if !ds.CompareAndSet("name", "a", "c") || !ds.CompareAndSet("new>key", nil, 1) {
+		t.Error("Error in CompareAndSet")
+	}
+	if v, _ := ds.GetInt("new>key"); v != 1 {
+		t.Errorf("Error in CompareAndSet of a new key: %d", v)
+	}
+	if v, err := ds.Increment("new>key", -5); err != nil || v != -4 {
+		t.Errorf("Error in Increment of a path: %d %v", v, err)
+	}
+	if _, err := ds.Increment("name", 1); err == nil {
+		t.Error("Error: Increment of a string should fail")
+	}
+	failure := errors.New("Error: rollback")
+	if err := ds.Update(func(d XDatasetDef) error { return failure }); err != failure {
+		t.Errorf("Error: Update should return the error of the function: %v", err)
+	}
+}
+
+func TestXDatasetCollectionTS_Transaction(t *testing.T) {
+	dc := &XDatasetCollectionTS{}
+	dc.Push(&XDataset{"hits": 0})
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				dc.Increment("0>hits", 1)
+				dc.Update(func(c XDatasetCollectionDef) error {
+					c.Push(&XDataset{"i": i})
+					return nil
+				})
+			}
+		}()
+	}
+	wg.Wait()
+	if hits, _ := dc.GetDataInt("@0>hits"); hits != 200 || dc.Count() != 201 {
+		t.Errorf("Error: lost updates %d %d", hits, dc.Count())
+	}
+	if entry, _ := dc.Get(10); fmt.Sprintf("%T", entry) != "*xcore.XDatasetTS" {
+		t.Error("Error: the entries pushed by Update are not encapsulated")
+	}
+	if !dc.CompareAndSet("0>hits", 200, 0) || dc.CompareAndSet("0>hits", 200, 0) {
+		t.Error("Error in CompareAndSet")
+	}
+	count := 0
+	dc.View(func(c XDatasetCollectionDef) error {
+		count = c.Count()
+		return nil
+	})
+	if count != 201 {
+		t.Errorf("Error in View: %d", count)
+	}
+	if _, err := dc.Increment("999>hits", 1); err == nil {
+		t.Error("Error: Increment out of range should fail")
+	}
+}
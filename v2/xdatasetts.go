@@ -1,6 +1,7 @@
 package xcore
 
 import (
+	"encoding/json"
 	"sync"
 	"time"
 )
@@ -12,14 +13,127 @@ type XDatasetTS struct {
 	data  XDatasetDef
 }
 
-// NewXDatasetTS builds a thread safe encapsulator on a XDataset compatible structure
+// NewXDatasetTS builds a thread safe encapsulator on a copy of a XDataset compatible structure.
+// The nested XDataset and XDatasetCollection of the copy are encapsulated too (see guardValue), so the whole tree is thread safe.
+// The structure of the caller is never modified nor shared: the changes made through the XDatasetTS are not visible into it
 func NewXDatasetTS(maindata XDatasetDef) *XDatasetTS {
+	if maindata != nil {
+		maindata = maindata.Clone()
+	}
+	return newGuardedTS(maindata)
+}
+
+// newGuardedTS will build the XDatasetTS on the structure, encapsulating in place its nested values. The structure belongs to the XDatasetTS
+func newGuardedTS(maindata XDatasetDef) *XDatasetTS {
+	guardNested(maindata)
 	ds := &XDatasetTS{
 		data: maindata,
 	}
 	return ds
 }
 
+// guardValue will encapsulate a copy of the value of the caller into its thread safe version: a XDatasetTS for a XDataset (or any dataset able to list its keys)
+// and a XDatasetCollectionTS for a XDatasetCollection, with all their nested values encapsulated too. The value of the caller is not modified nor shared.
+// The thread safe and immutable values are kept, and the other types must manage their own thread safety
+func guardValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case *XDatasetTS, *XDatasetCollectionTS, *XDatasetSnapshot, *snapshotCollection:
+		return v
+	case *XDatasetCollection:
+		return guardOwned(v.Clone())
+	case XDatasetDef:
+		if _, ok := datasetKeys(v); !ok {
+			return v
+		}
+		return guardOwned(v.Clone())
+	}
+	return value
+}
+
+// guardOwned will encapsulate in place the value that already belongs to the thread safe structure (a copy, or a value decoded from JSON) into its thread safe version
+func guardOwned(value interface{}) interface{} {
+	switch v := value.(type) {
+	case *XDatasetTS, *XDatasetCollectionTS, *XDatasetSnapshot, *snapshotCollection:
+		return v
+	case *XDatasetCollection:
+		data := make([]XDatasetDef, 0, len(*v))
+		for _, ds := range *v {
+			data = append(data, guardOwnedDataset(ds))
+		}
+		return &XDatasetCollectionTS{data: data}
+	case XDatasetDef:
+		if _, ok := datasetKeys(v); !ok {
+			return v
+		}
+		return newGuardedTS(v)
+	}
+	return value
+}
+
+// guardDataset will encapsulate a copy of the dataset of the caller into its thread safe version (see guardValue)
+func guardDataset(ds XDatasetDef) XDatasetDef {
+	if guarded, ok := guardValue(ds).(XDatasetDef); ok {
+		return guarded
+	}
+	return ds
+}
+
+// guardOwnedDataset will encapsulate in place the dataset that belongs to the thread safe structure (see guardOwned)
+func guardOwnedDataset(ds XDatasetDef) XDatasetDef {
+	if guarded, ok := guardOwned(ds).(XDatasetDef); ok {
+		return guarded
+	}
+	return ds
+}
+
+// guardNested will encapsulate in place the nested values of the dataset into their thread safe versions (see guardValue)
+func guardNested(ds XDatasetDef) {
+	if ds == nil {
+		return
+	}
+	keys, _ := datasetKeys(ds)
+	for _, key := range keys {
+		guardKey(ds, key)
+	}
+}
+
+// guardKey will encapsulate in place the value of the key of the dataset into its thread safe version, if needed
+func guardKey(ds XDatasetDef, key string) {
+	value, ok := datasetKey(ds, key)
+	if !ok {
+		return
+	}
+	switch value.(type) {
+	case *XDatasetTS, *XDatasetCollectionTS, *XDatasetSnapshot, *snapshotCollection:
+		return
+	case *XDatasetCollection, XDatasetDef:
+		setDatasetKey(ds, key, guardOwned(value))
+	}
+}
+
+// MarshalJSON will encode the XDatasetTS and all its nested data into JSON
+func (ds *XDatasetTS) MarshalJSON() ([]byte, error) {
+	ds.mutex.RLock()
+	defer ds.mutex.RUnlock()
+	if ds.data == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(ds.data)
+}
+
+// UnmarshalJSON will decode the JSON object into a new XDataset encapsulated by the XDatasetTS, replacing its content
+func (ds *XDatasetTS) UnmarshalJSON(data []byte) error {
+	temp := &XDataset{}
+	if err := json.Unmarshal(data, temp); err != nil {
+		return err
+	}
+	guardNested(temp)
+	ds.mutex.Lock()
+	ds.data = temp
+	ds.mutex.Unlock()
+	return nil
+}
+
 // String will transform the XDataset into a readable string for humans
 func (ds *XDatasetTS) String() string {
 	ds.mutex.RLock()
@@ -34,11 +148,48 @@ func (ds *XDatasetTS) GoString() string {
 	return ds.data.GoString()
 }
 
-// Set will add a variable key with value data to the XDatasetTS
+// Set will add a variable key with value data to the XDatasetTS. The key may be a path "a>b>c" (see XDataset.Set).
+// A copy of a XDataset or XDatasetCollection value is encapsulated into a XDatasetTS or XDatasetCollectionTS (see guardValue): the caller keeps its own value.
+// The created entries of the path are thread safe too
 func (ds *XDatasetTS) Set(key string, data interface{}) {
+	data = guardValue(data)
 	ds.mutex.Lock()
-	ds.data.Set(key, data)
-	ds.mutex.Unlock()
+	defer ds.mutex.Unlock()
+	if err := ds.setPath(key, data, false); err != nil {
+		setDatasetKey(ds.data, key, data)
+	}
+}
+
+// SetPath will set the data at the path key "a>b>c" of the XDatasetTS, creating the missing intermediate entries (see XDataset.SetPath).
+// The created entries and the data are encapsulated into their thread safe versions
+func (ds *XDatasetTS) SetPath(key string, data interface{}, collections bool) error {
+	ds.mutex.Lock()
+	defer ds.mutex.Unlock()
+	return ds.setPath(key, data, collections)
+}
+
+// setPath will set the data at the path key, encapsulating the data and the created entries. The caller must have the write lock
+func (ds *XDatasetTS) setPath(key string, data interface{}, collections bool) error {
+	err := setDatasetPath(ds.data, key, guardValue(data), collections)
+	head, _, _ := splitPath(key)
+	guardKey(ds.data, head)
+	return err
+}
+
+// getKey will read the value of the literal key, without following a path (see datasetKey)
+func (ds *XDatasetTS) getKey(key string) (interface{}, bool) {
+	ds.mutex.RLock()
+	defer ds.mutex.RUnlock()
+	return datasetKey(ds.data, key)
+}
+
+// setKey will set the data at the literal key, without following a path (see setDatasetKey).
+// It is used by the internal copies: the data already belongs to the XDatasetTS and is encapsulated in place
+func (ds *XDatasetTS) setKey(key string, data interface{}) {
+	data = guardOwned(data)
+	ds.mutex.Lock()
+	defer ds.mutex.Unlock()
+	setDatasetKey(ds.data, key, data)
 }
 
 // Get will read the value of the key variable
@@ -136,13 +287,29 @@ func (ds *XDatasetTS) GetTimeCollection(key string) ([]time.Time, bool) {
 	return ds.data.GetTimeCollection(key)
 }
 
-// Del will deletes the variable
+// Del will deletes the variable. The key may be a path "a>b>c" through the nested datasets and collections
 func (ds *XDatasetTS) Del(key string) {
 	ds.mutex.Lock()
 	ds.data.Del(key)
 	ds.mutex.Unlock()
 }
 
+// Exists will check if the key exists into the XDatasetTS. The key may be a path "a>b>c" through the nested datasets and collections
+func (ds *XDatasetTS) Exists(key string) bool {
+	ds.mutex.RLock()
+	defer ds.mutex.RUnlock()
+	_, ok := ds.data.Get(key)
+	return ok
+}
+
+// Keys will return the sorted list of the keys of the encapsulated dataset, if it is able to list them
+func (ds *XDatasetTS) Keys() []string {
+	ds.mutex.RLock()
+	defer ds.mutex.RUnlock()
+	keys, _ := datasetKeys(ds.data)
+	return keys
+}
+
 // Clone will creates a totally new data memory cloned from this object
 func (ds *XDatasetTS) Clone() XDatasetDef {
 	cloned := &XDatasetTS{}
@@ -3,6 +3,7 @@ package xcore
 import (
 	"fmt"
 	"math"
+	"sync"
 	"testing"
 	"time"
 )
@@ -44,7 +45,7 @@ func ExampleXDatasetTS() {
 	fmt.Println(datats)
 	// Output:
 	// xcore.XDataset{v1:123 v2:abc v3:true vpi:3.1415927 vt:2020-01-01 12:00:00 +0000 UTC}
-	// xcore.XDataset{clientname:Fred clientpicture:face.jpg hobbies:XDatasetCollection[0:xcore.XDataset{name:Football sport:yes} 1:xcore.XDataset{name:Ping-pong sport:yes} 2:xcore.XDataset{name:Swimming sport:yes} 3:xcore.XDataset{name:Videogames sport:no} ] metadata:xcore.XDataset{Salary:3568.65 hiredate:2020-01-01 12:00:00 +0000 UTC preferred-color:blue} preferredhobby:xcore.XDataset{name:Baseball sport:yes}}
+	// xcore.XDataset{clientname:Fred clientpicture:face.jpg hobbies:XDatasetCollectionTS[0:xcore.XDataset{name:Football sport:yes} 1:xcore.XDataset{name:Ping-pong sport:yes} 2:xcore.XDataset{name:Swimming sport:yes} 3:xcore.XDataset{name:Videogames sport:no} ] metadata:xcore.XDataset{Salary:3568.65 hiredate:2020-01-01 12:00:00 +0000 UTC preferred-color:blue} preferredhobby:xcore.XDataset{name:Baseball sport:yes}}
 }
 
 func TestXDatasetTS_simple_print(t *testing.T) {
@@ -111,13 +112,13 @@ func TestXDatasetTS_complex_print(t *testing.T) {
 	data := getComplexDatasetTS()
 
 	str := fmt.Sprintf("%v", data)
-	if str != "xcore.XDataset{clientname:Fred clientpicture:face.jpg hobbies:XDatasetCollection[0:xcore.XDataset{name:Football sport:yes} 1:xcore.XDataset{name:Ping-pong sport:yes} 2:xcore.XDataset{name:Swimming sport:yes} 3:xcore.XDataset{name:Videogames sport:no} ] metadata:xcore.XDataset{hascat:true hasdog:false hiredate:2020-01-01 12:00:00 +0000 UTC numdata1:0 numdata2:17 preferred-color:blue previoussalary:0 resume: salary:3568.65} preferredhobby:xcore.XDataset{name:Baseball sport:yes}}" {
+	if str != "xcore.XDataset{clientname:Fred clientpicture:face.jpg hobbies:XDatasetCollectionTS[0:xcore.XDataset{name:Football sport:yes} 1:xcore.XDataset{name:Ping-pong sport:yes} 2:xcore.XDataset{name:Swimming sport:yes} 3:xcore.XDataset{name:Videogames sport:no} ] metadata:xcore.XDataset{hascat:true hasdog:false hiredate:2020-01-01 12:00:00 +0000 UTC numdata1:0 numdata2:17 preferred-color:blue previoussalary:0 resume: salary:3568.65} preferredhobby:xcore.XDataset{name:Baseball sport:yes}}" {
 		t.Error("Error creating and printing complex XDataset " + str)
 		return
 	}
 
 	str = fmt.Sprintf("%#v", data)
-	if str != "#xcore.XDataset{clientname:\"Fred\" clientpicture:\"face.jpg\" hobbies:XDatasetCollection[0:xcore.XDataset{name:Football sport:yes} 1:xcore.XDataset{name:Ping-pong sport:yes} 2:xcore.XDataset{name:Swimming sport:yes} 3:xcore.XDataset{name:Videogames sport:no} ] metadata:#xcore.XDataset{hascat:true hasdog:false hiredate:time.Date(2020, time.January, 1, 12, 0, 0, 0, time.UTC) numdata1:0 numdata2:17 preferred-color:\"blue\" previoussalary:0 resume:\"\" salary:3568.65} preferredhobby:#xcore.XDataset{name:\"Baseball\" sport:\"yes\"}}" {
+	if str != "#xcore.XDataset{clientname:\"Fred\" clientpicture:\"face.jpg\" hobbies:XDatasetCollectionTS[0:xcore.XDataset{name:Football sport:yes} 1:xcore.XDataset{name:Ping-pong sport:yes} 2:xcore.XDataset{name:Swimming sport:yes} 3:xcore.XDataset{name:Videogames sport:no} ] metadata:#xcore.XDataset{hascat:true hasdog:false hiredate:time.Date(2020, time.January, 1, 12, 0, 0, 0, time.UTC) numdata1:0 numdata2:17 preferred-color:\"blue\" previoussalary:0 resume:\"\" salary:3568.65} preferredhobby:#xcore.XDataset{name:\"Baseball\" sport:\"yes\"}}" {
 		t.Error("Error creating and #printing complex XDataset " + str)
 		return
 	}
@@ -136,7 +137,7 @@ func TestXDatasetTS_Get(t *testing.T) {
 
 	v2, _ := data.Get("hobbies")
 	strv2 := fmt.Sprintf("%v", v2)
-	if strv2 != "XDatasetCollection[0:xcore.XDataset{name:Football sport:yes} 1:xcore.XDataset{name:Ping-pong sport:yes} 2:xcore.XDataset{name:Swimming sport:yes} 3:xcore.XDataset{name:Videogames sport:no} ]" {
+	if strv2 != "XDatasetCollectionTS[0:xcore.XDataset{name:Football sport:yes} 1:xcore.XDataset{name:Ping-pong sport:yes} 2:xcore.XDataset{name:Swimming sport:yes} 3:xcore.XDataset{name:Videogames sport:no} ]" {
 		t.Error("Error getting hobbies from XDataset " + strv2)
 		return
 	}
@@ -214,7 +215,7 @@ func TestXDatasetTS_GetCollection(t *testing.T) {
 	// 2. Gets a real dataset
 	v1, _ := data.GetCollection("hobbies") // is a collection
 	strv1 := fmt.Sprintf("%v", v1)
-	if strv1 != "XDatasetCollection[0:xcore.XDataset{name:Football sport:yes} 1:xcore.XDataset{name:Ping-pong sport:yes} 2:xcore.XDataset{name:Swimming sport:yes} 3:xcore.XDataset{name:Videogames sport:no} ]" {
+	if strv1 != "XDatasetCollectionTS[0:xcore.XDataset{name:Football sport:yes} 1:xcore.XDataset{name:Ping-pong sport:yes} 2:xcore.XDataset{name:Swimming sport:yes} 3:xcore.XDataset{name:Videogames sport:no} ]" {
 		t.Error("Error getting XDatasetCollection " + strv1)
 		return
 	}
@@ -245,7 +246,7 @@ func TestXDatasetTS_GetString(t *testing.T) {
 
 	// 2. Gets a real dataset
 	v1, _ := data.GetString("hobbies") // is a collection, printed
-	if v1 != "XDatasetCollection[0:xcore.XDataset{name:Football sport:yes} 1:xcore.XDataset{name:Ping-pong sport:yes} 2:xcore.XDataset{name:Swimming sport:yes} 3:xcore.XDataset{name:Videogames sport:no} ]" {
+	if v1 != "XDatasetCollectionTS[0:xcore.XDataset{name:Football sport:yes} 1:xcore.XDataset{name:Ping-pong sport:yes} 2:xcore.XDataset{name:Swimming sport:yes} 3:xcore.XDataset{name:Videogames sport:no} ]" {
 		t.Error("Error getting string " + v1)
 		return
 	}
@@ -522,8 +523,8 @@ func TestXDatasetTS_GetTime(t *testing.T) {
 			return
 		}
 	}
-	r, ok := data.GetTime("float641")
-	if !r.Equal(time.Time{}) || ok {
+	r, ok := data.GetTime("float641") // unix time
+	if !r.Equal(time.Unix(1, 0)) || !ok {
 		t.Error("Error getting time " + fmt.Sprintf("xxx %#v %#v", r, ok))
 		return
 	}
@@ -702,3 +703,106 @@ func TestXDatasetTS_Clone(t *testing.T) {
 }
 
 // Try race conditions
+
+func TestXDatasetTS_Deep(t *testing.T) {
+	data := NewXDatasetTS(&XDataset{
+		"config":  &XDataset{"db": &XDataset{"host": "localhost"}},
+		"servers": &XDatasetCollection{&XDataset{"ip": "10.0.0.1"}},
+	})
+	if config, _ := data.Get("config"); fmt.Sprintf("%T", config) != "*xcore.XDatasetTS" {
+		t.Errorf("Error: the nested dataset is not encapsulated: %T", config)
+	}
+	config, _ := data.GetDataset("config>db")
+	if _, ok := config.(*XDatasetTS); !ok {
+		t.Errorf("Error: the deep nested dataset is not encapsulated: %T", config)
+	}
+	servers, _ := data.GetCollection("servers")
+	if _, ok := servers.(*XDatasetCollectionTS); !ok {
+		t.Errorf("Error: the nested collection is not encapsulated: %T", servers)
+	}
+	servers.Push(&XDataset{"ip": "10.0.0.2"})
+	if server, _ := servers.Get(1); fmt.Sprintf("%T", server) != "*xcore.XDatasetTS" {
+		t.Errorf("Error: the new entry of the collection is not encapsulated: %T", server)
+	}
+	data.Set("cache", &XDataset{"ttl": 60})
+	data.SetPath("new>sub>value", 1, false)
+	data.Set("other>sub>value", 1)
+	for _, key := range []string{"cache", "new", "new>sub", "other", "other>sub"} {
+		if ds, _ := data.GetDataset(key); fmt.Sprintf("%T", ds) != "*xcore.XDatasetTS" {
+			t.Errorf("Error: the dataset %s is not encapsulated: %T", key, ds)
+		}
+	}
+
+	data.Set("name", "main")
+	data.Set("name>sub", 60)
+	if ttl, _ := data.Get("name>sub"); ttl != 60 {
+		t.Errorf("Error: Set must keep the literal key when the path cannot be followed: %v", ttl)
+	}
+
+	// the structure of the caller is not modified
+	source := &XDataset{"config": &XDataset{"host": "localhost"}, "servers": &XDatasetCollection{&XDataset{"ip": "10.0.0.1"}}}
+	NewXDatasetTS(source).SetPath("config>host", "remote", false)
+	if _, ok := (*source)["config"].(*XDataset); !ok {
+		t.Errorf("Error: NewXDatasetTS modified the nested dataset of the caller: %T", (*source)["config"])
+	}
+	if _, ok := (*source)["servers"].(*XDatasetCollection); !ok {
+		t.Errorf("Error: NewXDatasetTS modified the nested collection of the caller: %T", (*source)["servers"])
+	}
+	flat := &XDataset{"host": "localhost"}
+	NewXDatasetTS(flat).Set("host", "remote")
+	if host, _ := flat.GetString("host"); host != "localhost" {
+		t.Errorf("Error: NewXDatasetTS shares the dataset of the caller: %s", host)
+	}
+	raw := &XDataset{"ttl": 60, "sub": &XDataset{"size": 1}}
+	data.Set("raw", raw)
+	raw.Set("ttl", 120)
+	if ttl, _ := data.GetInt("raw>ttl"); ttl != 60 {
+		t.Errorf("Error: Set shares the dataset of the caller: %d", ttl)
+	}
+	if _, ok := (*raw)["sub"].(*XDataset); !ok {
+		t.Errorf("Error: Set modified the nested dataset of the caller: %T", (*raw)["sub"])
+	}
+	entry := &XDataset{"ip": "10.0.0.3"}
+	servers.Push(entry)
+	entry.Set("ip", "10.0.0.4")
+	if ip, _ := data.GetString("servers>2>ip"); ip != "10.0.0.3" {
+		t.Errorf("Error: Push shares the dataset of the caller: %s", ip)
+	}
+	servers.Pop()
+
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				switch i % 6 {
+				case 0:
+					data.SetPath("config>db>port", i, false)
+				case 1:
+					db, _ := data.GetDataset("config>db")
+					db.Set("user", w)
+				case 2:
+					data.Del("config>db>user")
+				case 3:
+					srv, _ := data.GetCollection("servers")
+					srv.Push(&XDataset{"ip": i})
+					srv.Pop()
+				case 4:
+					data.SetPath("servers>0>name", "main", false)
+					data.Get("servers>0>name")
+				case 5:
+					data.Set("temp", &XDataset{"w": w})
+					if temp, ok := data.GetDataset("temp"); ok {
+						temp.Set("i", i)
+					}
+					_ = data.String()
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	if host, _ := data.GetString("config>db>host"); host != "localhost" {
+		t.Errorf("Error: data lost: %v", data)
+	}
+}
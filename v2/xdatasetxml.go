@@ -0,0 +1,273 @@
+package xcore
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// XDatasetXMLOptions are the options to build a XDataset from XML and to build XML from a XDataset
+type XDatasetXMLOptions struct {
+	// AttrPrefix is the prefix of the keys of the attributes of the elements. Default is "-": <price currency="USD"> is the key -currency
+	AttrPrefix string
+	// TextKey is the key of the text of the elements that also have attributes or sub elements. Default is ".text"
+	TextKey string
+	// Collections is the list of names of elements that are always a collection, even if there is only one element
+	Collections []string
+	// Indent is the indentation of the generated XML. Default is no indentation
+	Indent string
+}
+
+// DefaultXDatasetXMLOptions are the options used when no options are given, and by MarshalXML and UnmarshalXML
+var DefaultXDatasetXMLOptions = XDatasetXMLOptions{
+	AttrPrefix: "-",
+	TextKey:    ".text",
+}
+
+// NewXDatasetFromXMLString will build a XDataset with the content of the root element of the XML document.
+// The sub elements become keys, the repeated elements become collections (XDatasetCollection for elements with sub elements or attributes, []string for simple elements),
+// the attributes are keys with the AttrPrefix, and the text of elements with attributes or sub elements is the TextKey.
+// All the values are strings.
+func NewXDatasetFromXMLString(data string, options *XDatasetXMLOptions) (*XDataset, error) {
+	if options == nil {
+		options = &DefaultXDatasetXMLOptions
+	}
+	decoder := xml.NewDecoder(strings.NewReader(data))
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil, errors.New("Error: there is no root element in the XML document")
+		}
+		if err != nil {
+			return nil, err
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			value, err := decodeXMLElement(decoder, start, options)
+			if err != nil {
+				return nil, err
+			}
+			if ds, ok := value.(*XDataset); ok {
+				return ds, nil
+			}
+			return &XDataset{options.TextKey: value}, nil
+		}
+	}
+}
+
+// NewXDatasetFromXMLFile will build a XDataset with the content of the root element of the XML file (see NewXDatasetFromXMLString)
+func NewXDatasetFromXMLFile(file string, options *XDatasetXMLOptions) (*XDataset, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	return NewXDatasetFromXMLString(string(data), options)
+}
+
+// GetXML will build the XML document of the dataset, with the root element.
+// The dataset must be able to list its keys (XDataset, XDatasetTS...). The keys that are not valid XML names are ignored.
+func GetXML(ds XDatasetDef, root string, options *XDatasetXMLOptions) (string, error) {
+	if options == nil {
+		options = &DefaultXDatasetXMLOptions
+	}
+	buffer := &bytes.Buffer{}
+	buffer.WriteString(xml.Header)
+	encoder := xml.NewEncoder(buffer)
+	encoder.Indent("", options.Indent)
+	if err := encodeXMLValue(encoder, root, ds, options); err != nil {
+		return "", err
+	}
+	if err := encoder.Flush(); err != nil {
+		return "", err
+	}
+	return buffer.String(), nil
+}
+
+// MarshalXML will encode the XDataset as the XML element start, with the DefaultXDatasetXMLOptions
+func (d *XDataset) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return encodeXMLValue(e, start.Name.Local, d, &DefaultXDatasetXMLOptions)
+}
+
+// UnmarshalXML will decode the XML element start into the XDataset, with the DefaultXDatasetXMLOptions
+func (d *XDataset) UnmarshalXML(decoder *xml.Decoder, start xml.StartElement) error {
+	value, err := decodeXMLElement(decoder, start, &DefaultXDatasetXMLOptions)
+	if err != nil {
+		return err
+	}
+	if ds, ok := value.(*XDataset); ok {
+		*d = *ds
+	} else {
+		*d = XDataset{DefaultXDatasetXMLOptions.TextKey: value}
+	}
+	return nil
+}
+
+// decodeXMLElement will decode the element start up to its end.
+// Returns a string if the element has only text, or a *XDataset if it has attributes or sub elements
+func decodeXMLElement(decoder *xml.Decoder, start xml.StartElement, options *XDatasetXMLOptions) (interface{}, error) {
+	ds := &XDataset{}
+	for _, attr := range start.Attr {
+		(*ds)[options.AttrPrefix+attr.Name.Local] = attr.Value
+	}
+	children := map[string][]interface{}{}
+	text := &strings.Builder{}
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch tk := token.(type) {
+		case xml.StartElement:
+			value, err := decodeXMLElement(decoder, tk, options)
+			if err != nil {
+				return nil, err
+			}
+			children[tk.Name.Local] = append(children[tk.Name.Local], value)
+		case xml.CharData:
+			text.Write(tk)
+		case xml.EndElement:
+			if len(*ds) == 0 && len(children) == 0 {
+				return text.String(), nil
+			}
+			if str := strings.TrimSpace(text.String()); str != "" {
+				(*ds)[options.TextKey] = str
+			}
+			for name, values := range children {
+				(*ds)[name] = xmlChildrenValue(values, isXMLCollection(name, options), options)
+			}
+			return ds, nil
+		}
+	}
+}
+
+// isXMLCollection will check if the element name must always be a collection
+func isXMLCollection(name string, options *XDatasetXMLOptions) bool {
+	for _, c := range options.Collections {
+		if c == name {
+			return true
+		}
+	}
+	return false
+}
+
+// xmlChildrenValue will build the value of the sub elements with the same name: a single value, a []string or a XDatasetCollection
+func xmlChildrenValue(values []interface{}, collection bool, options *XDatasetXMLOptions) interface{} {
+	if len(values) == 1 && !collection {
+		return values[0]
+	}
+	strs := []string{}
+	for _, v := range values {
+		if str, ok := v.(string); ok {
+			strs = append(strs, str)
+		}
+	}
+	if len(strs) == len(values) {
+		return strs
+	}
+	dsc := &XDatasetCollection{}
+	for _, v := range values {
+		if ds, ok := v.(*XDataset); ok {
+			dsc.Push(ds)
+		} else {
+			dsc.Push(&XDataset{options.TextKey: v})
+		}
+	}
+	return dsc
+}
+
+// encodeXMLValue will encode the value as the element name (or as many elements name if the value is a collection or a slice)
+func encodeXMLValue(encoder *xml.Encoder, name string, value interface{}, options *XDatasetXMLOptions) error {
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+	switch val := value.(type) {
+	case nil:
+		return encoder.EncodeElement("", start)
+	case XDatasetDef:
+		keys, ok := datasetKeys(val)
+		if !ok {
+			return fmt.Errorf("Error: the dataset %T cannot list its keys to build the XML", val)
+		}
+		children := []string{}
+		for _, key := range keys {
+			if options.AttrPrefix != "" && strings.HasPrefix(key, options.AttrPrefix) && isXMLName(key[len(options.AttrPrefix):]) {
+				attr, _ := val.GetString(key)
+				start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: key[len(options.AttrPrefix):]}, Value: attr})
+			} else if key == options.TextKey || isXMLName(key) {
+				children = append(children, key)
+			}
+		}
+		if err := encoder.EncodeToken(start); err != nil {
+			return err
+		}
+		for _, key := range children {
+			v, _ := val.Get(key)
+			if key == options.TextKey {
+				if err := encoder.EncodeToken(xml.CharData(xmlText(v))); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := encodeXMLValue(encoder, key, v, options); err != nil {
+				return err
+			}
+		}
+		return encoder.EncodeToken(start.End())
+	case XDatasetCollectionDef:
+		for i := 0; i < val.Count(); i++ {
+			ds, _ := val.Get(i)
+			if err := encodeXMLValue(encoder, name, ds, options); err != nil {
+				return err
+			}
+		}
+		return nil
+	case time.Time, string, []byte:
+		return encoder.EncodeElement(xmlText(val), start)
+	}
+	rv := reflect.ValueOf(value)
+	if rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array {
+		for i := 0; i < rv.Len(); i++ {
+			if err := encodeXMLValue(encoder, name, rv.Index(i).Interface(), options); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return encoder.EncodeElement(xmlText(value), start)
+}
+
+// xmlText will convert a value to the text of an element
+func xmlText(value interface{}) string {
+	switch val := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case []byte:
+		return string(val)
+	case time.Time:
+		return val.Format(time.RFC3339Nano)
+	}
+	return fmt.Sprint(value)
+}
+
+// isXMLName will check if the key is usable as a XML element or attribute name
+func isXMLName(key string) bool {
+	if key == "" {
+		return false
+	}
+	for i, r := range key {
+		if unicode.IsLetter(r) || r == '_' {
+			continue
+		}
+		if i > 0 && (unicode.IsDigit(r) || r == '-' || r == '.') {
+			continue
+		}
+		return false
+	}
+	return true
+}
@@ -0,0 +1,96 @@
+package xcore
+
+import (
+	"encoding/xml"
+	"testing"
+)
+
+func TestXDataset_XML(t *testing.T) {
+	ds, err := NewXDatasetFromXMLFile("testunit/feed.xml", &XDatasetXMLOptions{AttrPrefix: "-", TextKey: ".text", Collections: []string{"author"}})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	tests := map[string]string{
+		"-version":               "2.0",
+		"title":                  "XCore news",
+		"item>0>-id":             "1",
+		"item>0>title":           "First news",
+		"item>0>tag":             "[go xml]",
+		"item>1>price>-currency": "USD",
+		"item>1>price>.text":     "12.50",
+		"author>0>name":          "Fred",
+	}
+	for key, expected := range tests {
+		val, _ := ds.GetString(key)
+		if val != expected {
+			t.Errorf("Error reading %s from the XML: %s", key, val)
+		}
+	}
+	if _, ok := ds.GetCollection("item"); !ok {
+		t.Error("The repeated elements should be a collection")
+	}
+
+	// Inject directly into a template
+	tmpl, _ := NewXTemplateFromString("@@item@@[[item]]{{-id}}:{{title}};[[]]")
+	if result := tmpl.Execute(ds); result != "1:First news;2:Second news;" {
+		t.Errorf("Error injecting the XML into a template: %s", result)
+	}
+
+	// Back to XML
+	str, err := GetXML(ds, "feed", nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	expected := `<?xml version="1.0" encoding="UTF-8"?>` + "\n" +
+		`<feed version="2.0"><author><name>Fred</name></author><item id="1"><tag>go</tag><tag>xml</tag><title>First news</title></item><item id="2"><price currency="USD">12.50</price><title>Second news</title></item><title>XCore news</title></feed>`
+	if str != expected {
+		t.Errorf("Error building the XML: %s", str)
+		return
+	}
+
+	// And again from the generated XML
+	ds2, err := NewXDatasetFromXMLString(str, &XDatasetXMLOptions{AttrPrefix: "-", TextKey: ".text", Collections: []string{"author"}})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	str2, _ := GetXML(ds2, "feed", nil)
+	if str2 != str {
+		t.Errorf("Error in the XML round trip: %s", str2)
+	}
+}
+
+func TestXDataset_MarshalXML(t *testing.T) {
+	type page struct {
+		XMLName xml.Name  `xml:"page"`
+		Data    *XDataset `xml:"data"`
+	}
+	p := &page{Data: &XDataset{"name": "Fred", "-lang": "es", "scores": []int{1, 2}, "sub": &XDataset{"a": true}, ".counter": 1}}
+	code, err := xml.Marshal(p)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if string(code) != `<page><data lang="es"><name>Fred</name><scores>1</scores><scores>2</scores><sub><a>true</a></sub></data></page>` {
+		t.Errorf("Error marshaling the XDataset into XML: %s", code)
+		return
+	}
+
+	p2 := &page{}
+	err = xml.Unmarshal(code, p2)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if p2.Data.String() != "xcore.XDataset{-lang:es name:Fred scores:[1 2] sub:xcore.XDataset{a:true}}" {
+		t.Errorf("Error unmarshaling the XML into the XDataset: %s", p2.Data)
+	}
+
+	_, err = NewXDatasetFromXMLString("<a><b></a>", nil)
+	if err == nil {
+		t.Error("An invalid XML should not be loaded")
+	}
+}
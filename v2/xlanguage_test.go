@@ -43,7 +43,6 @@ func ExampleNewXLanguageFromXMLFile() {
 	fmt.Println(langEN.Get("entry1") + " " + langEN.Get("entry2"))
 	fmt.Println(langFR.Get("entry1") + " " + langFR.Get("entry2"))
 	// Output:
-
 	// Bienvenido a XCore
 	// Welcome to XCore
 	// Bienvenue à XCore
@@ -77,8 +76,6 @@ func TestXLanguage(t *testing.T) {
 		return
 	}
 
-	return
-
 	// from xml string
 	xmlstr, err := ioutil.ReadFile("./testunit/errors.es.xml")
 	if err != nil {
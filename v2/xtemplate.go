@@ -1,6 +1,7 @@
 package xcore
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io/ioutil"
@@ -9,6 +10,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 	//	"sync"
 )
 
@@ -59,6 +61,79 @@ const (
 	MetaUnused = -1 // a "not used anymore" param to be freed
 )
 
+// Errors returned by ExecuteContext when a limit of the execution is exceeded
+var (
+	ErrTemplateMaxDepth  = errors.New("Error: template max recursion depth exceeded")
+	ErrTemplateMaxOutput = errors.New("Error: template max output size exceeded")
+	ErrTemplateMaxLoops  = errors.New("Error: template max loop iterations exceeded")
+)
+
+// XTemplateLimits are the limits of an execution of the template, to protect the application against infinite recursion and user-editable templates.
+// A 0 value means no limit.
+type XTemplateLimits struct {
+	// MaxDepth is the max quantity of nested sub templates calls (&&, @@ and ??)
+	MaxDepth int
+	// MaxOutput is the max quantity of bytes of the generated string
+	MaxOutput int
+	// MaxLoops is the max quantity of iterations of all the loops of the execution
+	MaxLoops int
+	// Timeout is the max duration of the execution
+	Timeout time.Duration
+}
+
+// DefaultXTemplateLimits are the limits used by Execute and by ExecuteContext when no limits are given
+var DefaultXTemplateLimits = XTemplateLimits{MaxDepth: 1000}
+
+// xtemplateContext keeps the state of an execution of the template
+type xtemplateContext struct {
+	ctx      context.Context
+	limits   XTemplateLimits
+	language *XLanguage
+	format   string
+	depth    int
+	output   int
+	loops    int
+}
+
+// enter will check the context and the depth of recursion when entering a template
+func (xc *xtemplateContext) enter() error {
+	if err := xc.ctx.Err(); err != nil {
+		return err
+	}
+	xc.depth++
+	if xc.limits.MaxDepth > 0 && xc.depth > xc.limits.MaxDepth {
+		xc.depth--
+		return ErrTemplateMaxDepth
+	}
+	return nil
+}
+
+// leave will unstack the depth of recursion when leaving a template
+func (xc *xtemplateContext) leave() {
+	xc.depth--
+}
+
+// write will count the size of the generated string
+func (xc *xtemplateContext) write(size int) error {
+	xc.output += size
+	if xc.limits.MaxOutput > 0 && xc.output > xc.limits.MaxOutput {
+		return ErrTemplateMaxOutput
+	}
+	return nil
+}
+
+// loop will check the context and count the iterations of the loops
+func (xc *xtemplateContext) loop() error {
+	if err := xc.ctx.Err(); err != nil {
+		return err
+	}
+	xc.loops++
+	if xc.limits.MaxLoops > 0 && xc.loops > xc.limits.MaxLoops {
+		return ErrTemplateMaxLoops
+	}
+	return nil
+}
+
 // XTemplateParam is a parameter definition into the template
 type XTemplateParam struct {
 	ParamType int
@@ -80,6 +155,7 @@ type XTemplate struct {
 	Root         *XTemplateData
 	SubTemplates map[string]*XTemplate
 	Father       *XTemplate
+	Format       string
 	// mutex        sync.RWMutex
 }
 
@@ -143,15 +219,15 @@ func (t *XTemplate) compile(data string) error {
 			`|(#)#([a-zA-Z0-9-_\.]+?)##` + // index based 4
 
 			// ==== ELEMENTS
-			`|(&)&([a-zA-Z0-9-_\=\>\:\|\.]+?)&&` + // index based 6
-			`|(@)@([a-zA-Z0-9-_\=\>\:\|\.]+?)@@` + // index based 8
-			`|(\?)\?([a-zA-Z0-9-_\=\>\:\|\.]+?)\?\?` + // index based 10
-			`|(\!)\!([a-zA-Z0-9-_\=\>\:\|\.]+?)\!\!` + // index based 12
-			`|(\{)\{([a-zA-Z0-9-_\=\>\:\|\.]+?)\}\}` + // index based 14
+			`|(&)&([a-zA-Z0-9-_\=\>\:\|\.\/\@]+?)&&` + // index based 6
+			`|(@)@([a-zA-Z0-9-_\=\>\:\|\.\/\@\*\[\]\!]+?)@@` + // index based 8
+			`|(\?)\?([a-zA-Z0-9-_\=\>\:\|\.\/\@]+?)\?\?` + // index based 10
+			`|(\!)\!([a-zA-Z0-9-_\=\>\:\|\.\/\@]+?)\!\!` + // index based 12
+			`|(\{)\{([a-zA-Z0-9-_\=\>\:\|\.\/\@]+?)\}\}` + // index based 14
 
 			// ==== NESTED ELEMENTS (SUB TEMPLATES)
 			`|\[\[(\])\](\n|\r|\r\n|\n\r)?` + // index based 16
-			`|(\[)\[([a-z0-9\|\.\-_]+?)\]\](\n|\r|\r\n|\n\r)?` // index based 18
+			`|(\[)\[([a-zA-Z0-9\|\.\-_]+?)\]\](\n|\r|\r\n|\n\r)?` // index based 18
 
 	codex := regexp.MustCompile(code)
 	indexes := codex.FindAllStringIndex(data, -1)
@@ -300,36 +376,86 @@ func (t *XTemplate) GetTemplate(name string) *XTemplate {
 }
 
 // Execute will inject the Data into the template and creates the final string
+// The execution is protected with the DefaultXTemplateLimits. If a limit is reached, the error message is returned as the result.
 func (t *XTemplate) Execute(data XDatasetDef) string {
+	str, err := t.ExecuteContext(context.Background(), data, nil)
+	if err != nil {
+		return err.Error()
+	}
+	return str
+}
+
+// ExecuteContext will inject the Data into the template and creates the final string, controlled by the context and the limits.
+// If limits is nil, the DefaultXTemplateLimits are used.
+// Returns an error if the context is canceled, the timeout is reached or any of the limits is exceeded.
+func (t *XTemplate) ExecuteContext(ctx context.Context, data XDatasetDef, limits *XTemplateLimits) (string, error) {
+	xc := &xtemplateContext{ctx: ctx, limits: DefaultXTemplateLimits, format: t.Format}
+	if limits != nil {
+		xc.limits = *limits
+	}
+	if xc.limits.Timeout > 0 {
+		var cancel context.CancelFunc
+		xc.ctx, cancel = context.WithTimeout(ctx, xc.limits.Timeout)
+		defer cancel()
+	}
 	// Does data has a language ?
 	if data != nil {
-		var language *XLanguage
 		lang, _ := data.Get("#")
 		if lang != nil {
-			language, _ = lang.(*XLanguage) // language is nil if it-s not a *XLanguage
+			xc.language, _ = lang.(*XLanguage) // language is nil if it-s not a *XLanguage
 		}
 		stack := &XDatasetCollection{}
 		stack.Push(data)
-		return t.injector(stack, language)
+		return xc.result(t.injector(stack, xc))
 	}
-	return t.injector(nil, nil)
+	return xc.result(t.injector(nil, xc))
 }
 
 // injector will injects the data into this template
-func (t *XTemplate) injector(datacol XDatasetCollectionDef, language *XLanguage) string {
+func (t *XTemplate) injector(datacol XDatasetCollectionDef, xc *xtemplateContext) (string, error) {
 	var injected []string
 	if t.Root == nil {
-		return "Error, no template.Root compiled"
+		return "Error, no template.Root compiled", nil
+	}
+	if err := xc.enter(); err != nil {
+		return "", err
+	}
+	defer xc.leave()
+
+	// call will inject the data into the sub template and adds the result to the injected strings
+	call := func(subt *XTemplate) error {
+		substr, err := subt.injector(datacol, xc)
+		if err != nil {
+			return err
+		}
+		injected = append(injected, substr)
+		return nil
+	}
+	// add will add a final string to the injected strings
+	add := func(str string) error {
+		if err := xc.write(len(str)); err != nil {
+			return err
+		}
+		injected = append(injected, str)
+		return nil
 	}
+
 	for _, v := range *t.Root {
+		var err error
 		switch v.ParamType {
 		case MetaString: // included string from original code
-			injected = append(injected, v.Data)
+			err = add(v.Data)
 		case MetaComment:
 			// nothing to do: comment ignored
 		case MetaLanguage:
-			if language != nil {
-				injected = append(injected, language.Get(v.Data))
+			if xc.format == FormatJSON {
+				entry := ""
+				if xc.language != nil {
+					entry = xc.language.Get(v.Data)
+				}
+				err = add(jsonValue(entry))
+			} else if xc.language != nil {
+				err = add(xc.language.Get(v.Data))
 			}
 		case MetaReference: // Reference &&
 			xid := strings.Split(v.Data, ":")
@@ -339,13 +465,11 @@ func (t *XTemplate) injector(datacol XDatasetCollectionDef, language *XLanguage)
 				value, _ := datacol.GetDataString(field)
 				subt := t.GetTemplate(prefix + value)
 				if subt != nil {
-					substr := subt.injector(datacol, language)
-					injected = append(injected, substr)
+					err = call(subt)
 				} else {
 					subt := t.GetTemplate(prefix)
 					if subt != nil {
-						substr := subt.injector(datacol, language)
-						injected = append(injected, substr)
+						err = call(subt)
 					}
 				}
 			} else {
@@ -364,17 +488,22 @@ func (t *XTemplate) injector(datacol XDatasetCollectionDef, language *XLanguage)
 							datacol.Push(ds)
 						}
 					}
-					substr := subt.injector(datacol, language)
+					err = call(subt)
 					if withds {
 						datacol.Pop()
 					}
-					injected = append(injected, substr)
 				}
 			}
 		case MetaVariable: // {{id>id>id...}}
-			if datacol != nil {
+			if xc.format == FormatJSON {
+				var d interface{}
+				if datacol != nil {
+					d, _ = datacol.GetData(v.Data)
+				}
+				err = add(jsonValue(d))
+			} else if datacol != nil {
 				d, _ := datacol.GetDataString(v.Data)
-				injected = append(injected, d)
+				err = add(d)
 			}
 		case MetaRange: // Range (loop over subset) @@id:id@@
 			xdata := strings.Split(v.Data, ":")
@@ -387,42 +516,84 @@ func (t *XTemplate) injector(datacol XDatasetCollectionDef, language *XLanguage)
 			subt := t.GetTemplate(subtemplateid)
 			if subt != nil {
 				if datacol != nil {
-					cl, _ := datacol.GetCollection(subdataid)
-					if cl != nil && cl.Count() > 0 {
-						for i := 0; i < cl.Count(); i++ {
-							var tmp *XTemplate
-							tmp = t.GetTemplate(subtemplateid + ".key." + strconv.Itoa(i))
-							//							if tmp == nil {
-							//								tmp = t.GetTemplate(subtemplateid + ".field." + field + "." + value)
-							//							}
-							if tmp == nil && i == 0 {
-								tmp = t.GetTemplate(subtemplateid + ".first")
-							}
-							if tmp == nil && i == cl.Count()-1 {
-								tmp = t.GetTemplate(subtemplateid + ".last")
-							}
-							if tmp == nil && i%2 == 0 {
-								tmp = t.GetTemplate(subtemplateid + ".even")
-							}
-							if tmp == nil {
-								tmp = subt
+					var cl XDatasetCollectionDef
+					if isQuery(subdataid) {
+						cl = queryStack(datacol, subdataid)
+					} else {
+						cl, _ = datacol.GetCollection(subdataid)
+					}
+					separator := t.GetTemplate(subtemplateid + ".separator")
+					// entry will inject the entry i of the collection into the loop subtemplate
+					entry := func(i int, dcl XDatasetDef, last bool) error {
+						if err := xc.loop(); err != nil {
+							return err
+						}
+						if separator != nil && i > 0 {
+							if err := call(separator); err != nil {
+								return err
 							}
+						}
+						var tmp *XTemplate
+						key, keyed := collectionKey(cl, i)
+						if keyed {
+							tmp = t.GetTemplate(subtemplateid + ".key." + key)
+						}
+						if tmp == nil {
+							tmp = t.GetTemplate(subtemplateid + ".key." + strconv.Itoa(i))
+						}
+						//						if tmp == nil {
+						//							tmp = t.GetTemplate(subtemplateid + ".field." + field + "." + value)
+						//						}
+						if tmp == nil && i == 0 {
+							tmp = t.GetTemplate(subtemplateid + ".first")
+						}
+						if tmp == nil && last {
+							tmp = t.GetTemplate(subtemplateid + ".last")
+						}
+						if tmp == nil && i%2 == 0 {
+							tmp = t.GetTemplate(subtemplateid + ".even")
+						}
+						if tmp == nil {
+							tmp = subt
+						}
+						// the loop fields are read from an overlay, the entry is not modified (it may be immutable)
+						fields := XDataset{".counter": i + 1}
+						if keyed {
+							fields[".key"] = key
+						}
+						if dcl == nil {
+							// a nil entry of the collection is an empty dataset for the loop
+							dcl = &XDataset{}
+						}
+						datacol.Push(&loopEntry{XDatasetDef: dcl, fields: fields})
+						err := call(tmp)
+						// unstack extra data
+						datacol.Pop()
+						return err
+					}
+					count := 0
+					if sequential, ok := cl.(interface {
+						Each(func(int, XDatasetDef, bool) error) error
+					}); ok {
+						// sequential collections are read only once, with a look-ahead for the last entry
+						err = sequential.Each(func(i int, dcl XDatasetDef, last bool) error {
+							count++
+							return entry(i, dcl, last)
+						})
+					} else if cl != nil {
+						count = cl.Count()
+						for i := 0; i < count && err == nil; i++ {
 							dcl, _ := cl.Get(i)
-							dcl.Set(".counter", i+1)
-							datacol.Push(dcl)
-							substr := tmp.injector(datacol, language)
-							injected = append(injected, substr)
-							// unstack extra data
-							datacol.Pop()
+							err = entry(i, dcl, i == count-1)
 						}
-					} else {
+					}
+					if count == 0 && err == nil {
 						var tmp *XTemplate
 						tmp = t.GetTemplate(subtemplateid + ".none")
 						if tmp == nil {
 							tmp = subt
 						}
-						substr := tmp.injector(datacol, language)
-						injected = append(injected, substr)
+						err = call(tmp)
 					}
 				}
 			}
@@ -456,21 +627,19 @@ func (t *XTemplate) injector(datacol XDatasetCollectionDef, language *XLanguage)
 					if tmp != nil {
 						subt = tmp
 					}
-					substr := subt.injector(datacol, language)
-					injected = append(injected, substr)
+					err = call(subt)
 				}
 				if withds {
 					datacol.Pop()
 				}
 			}
-			if value == nil || fmt.Sprint(value) == "" {
+			if err == nil && (value == nil || fmt.Sprint(value) == "") {
 				tmp := t.GetTemplate(subtemplateid + ".none")
 				if tmp != nil {
 					subt = tmp
 				}
 				if subt != nil {
-					substr := subt.injector(datacol, language)
-					injected = append(injected, substr)
+					err = call(subt)
 				}
 			}
 		case MetaDump:
@@ -478,17 +647,23 @@ func (t *XTemplate) injector(datacol XDatasetCollectionDef, language *XLanguage)
 				if v.Data == "dump" || v.Data == "list" {
 					dsubstr, _ := datacol.Get(0)
 					if dsubstr != nil {
-						substr := dsubstr.GoString()
-						injected = append(injected, substr)
+						if xc.format == FormatJSON {
+							err = add(jsonValue(dsubstr))
+						} else {
+							err = add(dsubstr.GoString())
+						}
 					}
 				}
 			}
 		default:
-			injected = append(injected, "THE METALANGUAGE FROM OUTERSPACE IS NOT SUPPORTED: "+fmt.Sprint(v.ParamType))
+			err = add("THE METALANGUAGE FROM OUTERSPACE IS NOT SUPPORTED: " + fmt.Sprint(v.ParamType))
+		}
+		if err != nil {
+			return "", err
 		}
 	}
 	// return the page string
-	return strings.Join(injected, "")
+	return strings.Join(injected, ""), nil
 }
 
 // String will transform the XDataset into a readable string for humans
@@ -519,7 +694,7 @@ func (t *XTemplate) GoString() string {
 
 // Clone will make a full new copy of the template into a new memory space
 func (t *XTemplate) Clone() *XTemplate {
-	cloned := &XTemplate{Name: t.Name}
+	cloned := &XTemplate{Name: t.Name, Format: t.Format}
 	if t.Root != nil {
 		var newroot XTemplateData
 		for _, td := range *t.Root {
@@ -539,3 +714,120 @@ func (t *XTemplate) Clone() *XTemplate {
 
 	return cloned
 }
+
+// loopEntry is an entry of a collection into the stack of a loop, with the loop fields .counter and .key over the fields of the entry.
+// All the getters read the loop fields first
+type loopEntry struct {
+	XDatasetDef
+	fields XDataset
+}
+
+// Get will read the loop field, or the value of the key into the entry
+func (e *loopEntry) Get(key string) (interface{}, bool) {
+	if val, ok := e.fields[key]; ok {
+		return val, true
+	}
+	return e.XDatasetDef.Get(key)
+}
+
+// GetDataset will read the value of the key as a XDatasetDef cast type
+func (e *loopEntry) GetDataset(key string) (XDatasetDef, bool) {
+	if _, ok := e.fields[key]; ok {
+		return nil, false
+	}
+	return e.XDatasetDef.GetDataset(key)
+}
+
+// GetCollection will read the value of the key as a XDatasetCollectionDef cast type
+func (e *loopEntry) GetCollection(key string) (XDatasetCollectionDef, bool) {
+	if _, ok := e.fields[key]; ok {
+		return nil, false
+	}
+	return e.XDatasetDef.GetCollection(key)
+}
+
+// GetString will read the loop field, or the value of the key into the entry, as a string cast type
+func (e *loopEntry) GetString(key string) (string, bool) {
+	if val, ok := e.fields[key]; ok {
+		return convertString(val)
+	}
+	return e.XDatasetDef.GetString(key)
+}
+
+// GetBool will read the loop field, or the value of the key into the entry, as a boolean cast type
+func (e *loopEntry) GetBool(key string) (bool, bool) {
+	if val, ok := e.fields[key]; ok {
+		return convertBool(val)
+	}
+	return e.XDatasetDef.GetBool(key)
+}
+
+// GetInt will read the loop field, or the value of the key into the entry, as an integer cast type
+func (e *loopEntry) GetInt(key string) (int, bool) {
+	if val, ok := e.fields[key]; ok {
+		return convertInt(val)
+	}
+	return e.XDatasetDef.GetInt(key)
+}
+
+// GetFloat will read the loop field, or the value of the key into the entry, as a float64 cast type
+func (e *loopEntry) GetFloat(key string) (float64, bool) {
+	if val, ok := e.fields[key]; ok {
+		return convertFloat(val)
+	}
+	return e.XDatasetDef.GetFloat(key)
+}
+
+// GetTime will read the loop field, or the value of the key into the entry, as a time cast type
+func (e *loopEntry) GetTime(key string) (time.Time, bool) {
+	if val, ok := e.fields[key]; ok {
+		return convertTime(val)
+	}
+	return e.XDatasetDef.GetTime(key)
+}
+
+// GetStringCollection will read the loop field, or the value of the key into the entry, as a collection of strings cast type
+func (e *loopEntry) GetStringCollection(key string) ([]string, bool) {
+	if val, ok := e.fields[key]; ok {
+		return convertStringCollection(val)
+	}
+	return e.XDatasetDef.GetStringCollection(key)
+}
+
+// GetBoolCollection will read the loop field, or the value of the key into the entry, as a collection of bool cast type
+func (e *loopEntry) GetBoolCollection(key string) ([]bool, bool) {
+	if val, ok := e.fields[key]; ok {
+		return convertBoolCollection(val)
+	}
+	return e.XDatasetDef.GetBoolCollection(key)
+}
+
+// GetIntCollection will read the loop field, or the value of the key into the entry, as a collection of int cast type
+func (e *loopEntry) GetIntCollection(key string) ([]int, bool) {
+	if val, ok := e.fields[key]; ok {
+		return convertIntCollection(val)
+	}
+	return e.XDatasetDef.GetIntCollection(key)
+}
+
+// GetFloatCollection will read the loop field, or the value of the key into the entry, as a collection of float cast type
+func (e *loopEntry) GetFloatCollection(key string) ([]float64, bool) {
+	if val, ok := e.fields[key]; ok {
+		return convertFloatCollection(val)
+	}
+	return e.XDatasetDef.GetFloatCollection(key)
+}
+
+// GetTimeCollection will read the loop field, or the value of the key into the entry, as a collection of time cast type
+func (e *loopEntry) GetTimeCollection(key string) ([]time.Time, bool) {
+	if val, ok := e.fields[key]; ok {
+		return convertTimeCollection(val)
+	}
+	return e.XDatasetDef.GetTimeCollection(key)
+}
+
+// Keys will return the keys of the entry, so the queries can select into it
+func (e *loopEntry) Keys() []string {
+	keys, _ := datasetKeys(e.XDatasetDef)
+	return keys
+}
@@ -1,6 +1,7 @@
 package xcore
 
 import (
+	"context"
 	"fmt"
 	"testing"
 	"time"
@@ -477,3 +478,89 @@ Some data:
 	fmt.Println("Result: ", result)
 }
 */
+
+func TestXTemplateLimits(t *testing.T) {
+	// A template that calls itself forever
+	tmpl, err := NewXTemplateFromString("[[a]]A&&a&&[[]]&&a&&")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	_, err = tmpl.ExecuteContext(context.Background(), &XDataset{}, &XTemplateLimits{MaxDepth: 50})
+	if err != ErrTemplateMaxDepth {
+		t.Errorf("The recursion of the template should be stopped: %v", err)
+		return
+	}
+	// Execute uses the default limits
+	result := tmpl.Execute(&XDataset{})
+	if result != ErrTemplateMaxDepth.Error() {
+		t.Errorf("The recursion of the template should be stopped by Execute: %s", result)
+		return
+	}
+
+	tmpl, _ = NewXTemplateFromString("@@hobbies@@[[hobbies]]{{name}},[[]]")
+	data := &XDataset{
+		"hobbies": &XDatasetCollection{
+			&XDataset{"name": "Football"},
+			&XDataset{"name": "Ping-pong"},
+			&XDataset{"name": "Swimming"},
+		},
+	}
+	result, err = tmpl.ExecuteContext(context.Background(), data, &XTemplateLimits{MaxLoops: 3, MaxOutput: 100})
+	if err != nil || result != "Football,Ping-pong,Swimming," {
+		t.Errorf("The template should be executed under the limits: %s %v", result, err)
+		return
+	}
+	_, err = tmpl.ExecuteContext(context.Background(), data, &XTemplateLimits{MaxLoops: 2})
+	if err != ErrTemplateMaxLoops {
+		t.Errorf("The loop of the template should be stopped: %v", err)
+		return
+	}
+	_, err = tmpl.ExecuteContext(context.Background(), data, &XTemplateLimits{MaxOutput: 20})
+	if err != ErrTemplateMaxOutput {
+		t.Errorf("The output of the template should be stopped: %v", err)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = tmpl.ExecuteContext(ctx, data, nil)
+	if err != context.Canceled {
+		t.Errorf("The execution of the template should be canceled: %v", err)
+		return
+	}
+	ctx, cancel = context.WithTimeout(context.Background(), -time.Second)
+	defer cancel()
+	_, err = tmpl.ExecuteContext(ctx, data, &XTemplateLimits{Timeout: time.Minute})
+	if err != context.DeadlineExceeded {
+		t.Errorf("The execution of the template should time out: %v", err)
+		return
+	}
+}
+
+func TestXTemplateScopeSelectors(t *testing.T) {
+	tmpl, err := NewXTemplateFromString(`@@hobbies:hobby@@&&detail:preferredhobby&&
+[[hobby]]{{name}} of {{..>name}} in {{/>appname}} ({{@0>name}}) ??/>vip:vip??
+[[]]
+[[vip]]VIP[[]]
+[[detail]]{{name}}/{{..>name}}/@@/>hobbies:count@@[[count]]{{.counter}}[[]][[]]`)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	data := &XDataset{
+		"appname": "XCore",
+		"name":    "Fred",
+		"vip":     true,
+		"hobbies": &XDatasetCollection{
+			&XDataset{"name": "Football"},
+			&XDataset{"name": "Ping-pong"},
+		},
+		"preferredhobby": &XDataset{"name": "Baseball"},
+	}
+	result := tmpl.Execute(data)
+	if result != "Football of Fred in XCore (Fred) VIP\nPing-pong of Fred in XCore (Fred) VIP\nBaseball/Fred/12\n" {
+		t.Errorf("The scope selectors are not working: %s", result)
+		return
+	}
+}
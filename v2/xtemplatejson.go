@@ -0,0 +1,31 @@
+package xcore
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrTemplateInvalidJSON is returned by ExecuteContext when a JSON template does not build a valid JSON code
+var ErrTemplateInvalidJSON = errors.New("Error: the JSON template did not build a valid JSON code")
+
+// jsonValue will encode the value into JSON code according to its type: strings are quoted and escaped, times are RFC 3339 strings, nil is null, etc.
+// The values that cannot be encoded into JSON are encoded as their string representation.
+func jsonValue(value interface{}) string {
+	code, err := json.Marshal(value)
+	if err != nil {
+		code, _ = json.Marshal(fmt.Sprint(value))
+	}
+	return string(code)
+}
+
+// result will verify the final result of the execution: a JSON template must build a valid JSON code
+func (xc *xtemplateContext) result(str string, err error) (string, error) {
+	if err != nil {
+		return "", err
+	}
+	if xc.format == FormatJSON && !json.Valid([]byte(str)) {
+		return "", ErrTemplateInvalidJSON
+	}
+	return str, nil
+}
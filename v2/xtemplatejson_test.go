@@ -0,0 +1,104 @@
+package xcore
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestXTemplateJSON(t *testing.T) {
+	tmpl, err := NewXTemplateFromString(`{
+  "name": {{name}},
+  "quote": {{quote}},
+  "age": {{age}},
+  "salary": {{salary}},
+  "active": {{active}},
+  "hired": {{hired}},
+  "tags": {{tags}},
+  "missing": {{missing}},
+  "title": ##title##,
+  "hobbies": [@@hobbies:hobby@@]
+}
+[[hobby]]{"name": {{name}}, "counter": {{.counter}}}[[]]
+[[hobby.separator]],[[]]
+`)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	tmpl.Format = FormatJSON
+
+	hired, _ := time.Parse(time.RFC3339, "2020-01-01T12:00:00Z")
+	lang, _ := NewXLanguageFromString("title=The \"best\" client\n")
+	data := &XDataset{
+		"name":   "Fred",
+		"quote":  "He said \"hello\"\n<b>",
+		"age":    42,
+		"salary": 3568.65,
+		"active": true,
+		"hired":  hired,
+		"tags":   []string{"a", "b"},
+		"hobbies": &XDatasetCollection{
+			&XDataset{"name": "Football"},
+			&XDataset{"name": "Ping-pong"},
+			&XDataset{"name": "Swimming"},
+		},
+		"#": lang,
+	}
+
+	result, err := tmpl.ExecuteContext(context.Background(), data, nil)
+	if err != nil {
+		t.Errorf("Error executing the JSON template: %v %s", err, result)
+		return
+	}
+	decoded := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(result), &decoded); err != nil {
+		t.Errorf("The JSON template built an invalid JSON: %v %s", err, result)
+		return
+	}
+	if decoded["quote"] != "He said \"hello\"\n<b>" || decoded["age"] != 42.0 || decoded["active"] != true ||
+		decoded["hired"] != "2020-01-01T12:00:00Z" || decoded["missing"] != nil || decoded["title"] != "The \"best\" client" {
+		t.Errorf("The JSON values are not correctly encoded: %s", result)
+		return
+	}
+	hobbies, ok := decoded["hobbies"].([]interface{})
+	if !ok || len(hobbies) != 3 {
+		t.Errorf("The JSON loop is not correctly separated: %s", result)
+		return
+	}
+
+	// a template that builds an invalid JSON
+	tmpl, _ = NewXTemplateFromString(`[@@hobbies:hobby@@][[hobby]]{{name}},[[]]`)
+	tmpl.Format = FormatJSON
+	_, err = tmpl.ExecuteContext(context.Background(), data, nil)
+	if err != ErrTemplateInvalidJSON {
+		t.Errorf("The invalid JSON should be detected: %v", err)
+		return
+	}
+
+	// the JSON mode is opt-in: a template of a set keeps its quoted fields
+	ts := NewXTemplateSet()
+	if err := ts.LoadFile("testunit/page.json.template"); err != nil {
+		t.Error(err)
+		return
+	}
+	result, _, err = ts.Execute("page", FormatJSON, &XDataset{"name": "Fred"})
+	if err != nil || result != "{\"name\": \"Fred\"}\n" {
+		t.Errorf("The template of the set should not be in JSON mode: %s %v", result, err)
+	}
+}
+
+func TestXTemplateSeparator(t *testing.T) {
+	tmpl, _ := NewXTemplateFromString(`@@hobbies@@[[hobbies]]{{name}}[[]][[hobbies.separator]], [[]]`)
+	data := &XDataset{
+		"hobbies": &XDatasetCollection{
+			&XDataset{"name": "Football"},
+			&XDataset{"name": "Ping-pong"},
+		},
+	}
+	result := tmpl.Execute(data)
+	if result != "Football, Ping-pong" {
+		t.Errorf("The separator of the loop is not correct: %s", result)
+	}
+}
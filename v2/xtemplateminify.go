@@ -0,0 +1,221 @@
+package xcore
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+// Formats of the content of the templates
+const (
+	FormatText = "text"
+	FormatHTML = "html"
+	FormatXML  = "xml"
+)
+
+// XTemplateMinify are the options of the minification of a HTML or XML template
+type XTemplateMinify struct {
+	// Preserve is the list of tags (lowercase) whose content is kept untouched.
+	// If nil, the default is pre, textarea and script for HTML, nothing for XML.
+	Preserve []string
+	// KeepComments will keep the <!-- --> comments into the code.
+	KeepComments bool
+}
+
+var (
+	minifySpaces    = regexp.MustCompile(`\s+`)
+	minifyTagSpaces = regexp.MustCompile(`>\s+<`)
+)
+
+// minifyEdge marks the edges of the template as the character before or after a piece of code
+const minifyEdge byte = 1
+
+// Minify will remove the HTML comments and the insignificant white spaces of the strings of the template and all its sub templates.
+// It is a compilation pass, so it must be called after the template is loaded, and the template must have the Format FormatHTML or FormatXML.
+// The meta elements of the template are not modified.
+//
+// For HTML, any run of white spaces is collapsed into one space (the spaces between inline elements are meaningful), and the spaces at the edges of the template are removed.
+// For XML, only the white spaces between tags are removed.
+// The content of the preserved tags and the CDATA sections are never modified.
+func (t *XTemplate) Minify(options *XTemplateMinify) error {
+	if t.Format != FormatHTML && t.Format != FormatXML {
+		return errors.New("Error: only HTML and XML templates can be minified")
+	}
+	if options == nil {
+		options = &XTemplateMinify{}
+	}
+	preserve := options.Preserve
+	if preserve == nil && t.Format == FormatHTML {
+		preserve = []string{"pre", "textarea", "script"}
+	}
+	m := &xtemplateMinifier{
+		html:         t.Format == FormatHTML,
+		preserve:     preserve,
+		keepcomments: options.KeepComments,
+	}
+	m.minify(t, map[*XTemplate]bool{})
+	return nil
+}
+
+// xtemplateMinifier keeps the state of the minification of the strings of a template
+type xtemplateMinifier struct {
+	html         bool
+	preserve     []string
+	keepcomments bool
+	// closing is the end of the preserved block we are into, if any (it may start in a string and finish in another one)
+	closing string
+}
+
+// minify will minify the root of the template and its sub templates (only once each, they may be shared by many ids)
+func (m *xtemplateMinifier) minify(t *XTemplate, done map[*XTemplate]bool) {
+	if done[t] {
+		return
+	}
+	done[t] = true
+	if t.Root != nil {
+		m.closing = ""
+		// first we concatenate the strings separated by comments to minify the whole code
+		var root XTemplateData
+		for _, p := range *t.Root {
+			if p.ParamType == MetaComment {
+				continue
+			}
+			last := len(root) - 1
+			if p.ParamType == MetaString && last >= 0 && root[last].ParamType == MetaString {
+				root[last].Data += p.Data
+				continue
+			}
+			root = append(root, p)
+		}
+		for i := range root {
+			if root[i].ParamType == MetaString {
+				root[i].Data = m.minifyString(root[i].Data, i == 0, i == len(root)-1)
+			}
+		}
+		// empty strings are not needed anymore
+		compiled := root[:0]
+		for _, p := range root {
+			if p.ParamType != MetaString || p.Data != "" {
+				compiled = append(compiled, p)
+			}
+		}
+		t.Root = &compiled
+	}
+	for _, st := range t.SubTemplates {
+		m.minify(st, done)
+	}
+}
+
+// minifyString will minify a string of the template. first and last indicate if the string is at the edges of the template.
+func (m *xtemplateMinifier) minifyString(data string, first bool, last bool) string {
+	var result strings.Builder
+	// prev is the character before the piece of code to minify
+	var prev byte
+	if first {
+		prev = minifyEdge
+	}
+	write := func(str string) {
+		if len(str) > 0 {
+			result.WriteString(str)
+			prev = str[len(str)-1]
+		}
+	}
+	for len(data) > 0 {
+		if m.closing != "" {
+			// we are into a preserved block: copy until the closing
+			pos := strings.Index(strings.ToLower(data), m.closing)
+			if pos < 0 {
+				write(data)
+				return result.String()
+			}
+			write(data[:pos+len(m.closing)])
+			data = data[pos+len(m.closing):]
+			m.closing = ""
+			continue
+		}
+		pos, opening, closing := m.nextBlock(data)
+		if pos < 0 {
+			var next byte
+			if last {
+				next = minifyEdge
+			}
+			write(m.minifyText(data, prev, next))
+			break
+		}
+		write(m.minifyText(data[:pos], prev, '<'))
+		data = data[pos:]
+		if opening == "<!--" && !m.keepcomments && !strings.HasPrefix(data, "<!--[if") {
+			end := strings.Index(data, closing)
+			if end >= 0 {
+				data = data[end+len(closing):]
+				continue
+			}
+			// The comment is not closed into this string: we cannot remove it
+		}
+		write(data[:len(opening)])
+		data = data[len(opening):]
+		m.closing = closing
+	}
+	return result.String()
+}
+
+// nextBlock searches the first comment, CDATA section or preserved tag into the data.
+// Returns the position, the opening and closing strings of the block, or -1 if there is none.
+func (m *xtemplateMinifier) nextBlock(data string) (int, string, string) {
+	lower := strings.ToLower(data)
+	pos, opening, closing := -1, "", ""
+	check := func(p int, o string, c string) {
+		if p >= 0 && (pos < 0 || p < pos) {
+			pos, opening, closing = p, o, c
+		}
+	}
+	check(strings.Index(data, "<!--"), "<!--", "-->")
+	check(strings.Index(data, "<![CDATA["), "<![CDATA[", "]]>")
+	for _, tag := range m.preserve {
+		offset := 0
+		for {
+			p := strings.Index(lower[offset:], "<"+tag)
+			if p < 0 {
+				break
+			}
+			p += offset
+			next := p + len(tag) + 1
+			if next >= len(lower) || strings.ContainsRune(" \t\r\n/>", rune(lower[next])) {
+				check(p, data[p:next], "</"+tag)
+				break
+			}
+			offset = next
+		}
+	}
+	return pos, opening, closing
+}
+
+// minifyText will remove the insignificant spaces of a piece of code without any preserved block.
+// prev and next are the characters around the piece of code (0 if unknown, for instance a meta element, minifyEdge at the edges of the template).
+func (m *xtemplateMinifier) minifyText(data string, prev byte, next byte) string {
+	if data == "" {
+		return ""
+	}
+	if m.html {
+		// the spaces between inline elements are meaningful: they are only collapsed, and removed at the edges of the template
+		data = minifySpaces.ReplaceAllString(data, " ")
+		if prev == minifyEdge || prev == ' ' {
+			data = strings.TrimLeft(data, " ")
+		}
+		if next == minifyEdge {
+			data = strings.TrimRight(data, " ")
+		}
+		return data
+	}
+	// the edges of the template work as tags
+	if prev == minifyEdge {
+		prev = '>'
+	}
+	if next == minifyEdge {
+		next = '<'
+	}
+	// the characters around are added to find the spaces between tags, then removed
+	data = string([]byte{prev}) + data + string([]byte{next})
+	data = minifyTagSpaces.ReplaceAllString(data, "><")
+	return data[1 : len(data)-1]
+}
@@ -0,0 +1,92 @@
+package xcore
+
+import (
+	"testing"
+)
+
+func TestXTemplateMinifyHTML(t *testing.T) {
+	tmpl, err := NewXTemplateFromString(`<html>
+  <!-- main page -->
+  <body>
+    <h1>Hello   {{name}}</h1>
+    <pre>
+  keep   this
+  {{code}}   too
+    </pre>
+    <ul>
+      @@hobbies@@
+    </ul>
+    <script>
+      var a = 1;  // <!-- not a comment -->
+    </script>
+  </body>
+</html>
+[[hobbies]]
+      <li>{{name}}</li>
+[[]]
+`)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	err = tmpl.Minify(nil)
+	if err == nil {
+		t.Error("A text template should not be minified")
+		return
+	}
+	tmpl.Format = FormatHTML
+	err = tmpl.Minify(nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	data := &XDataset{
+		"name": "Fred",
+		"code": "x = 1",
+		"hobbies": &XDatasetCollection{
+			&XDataset{"name": "Football"},
+			&XDataset{"name": "Swimming"},
+		},
+	}
+	result := tmpl.Execute(data)
+	expected := `<html> <body> <h1>Hello Fred</h1> <pre>
+  keep   this
+  x = 1   too
+    </pre> <ul> <li>Football</li><li>Swimming</li> </ul> <script>
+      var a = 1;  // <!-- not a comment -->
+    </script> </body> </html>`
+	if result != expected {
+		t.Errorf("Error minifying the HTML template: %s", result)
+		return
+	}
+
+	// the spaces between inline elements are kept
+	inline, _ := NewXTemplateFromString("  <p><b>a</b>   <i>b</i>\n  <!-- c -->  <span>c</span></p>\n")
+	inline.Format = FormatHTML
+	inline.Minify(nil)
+	if result := inline.Execute(&XDataset{}); result != "<p><b>a</b> <i>b</i> <span>c</span></p>" {
+		t.Errorf("Error minifying the inline HTML elements: %q", result)
+	}
+}
+
+func TestXTemplateMinifyXML(t *testing.T) {
+	tmpl, _ := NewXTemplateFromString(`<?xml version="1.0" encoding="UTF-8"?>
+<client>
+  <!-- data -->
+  <name>  {{name}}  </name>
+  <note><![CDATA[  a   <b>  ]]></note>
+</client>
+`)
+	tmpl.Format = FormatXML
+	err := tmpl.Minify(&XTemplateMinify{KeepComments: true})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	result := tmpl.Execute(&XDataset{"name": "Fred"})
+	if result != `<?xml version="1.0" encoding="UTF-8"?><client><!-- data --><name>  Fred  </name><note><![CDATA[  a   <b>  ]]></note></client>` {
+		t.Errorf("Error minifying the XML template: %s", result)
+		return
+	}
+}
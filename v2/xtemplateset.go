@@ -0,0 +1,271 @@
+package xcore
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// FormatJSON is the format of the templates that build JSON code
+const FormatJSON = "json"
+
+// XTemplateMimeTypes are the MIME types of the formats of templates, used for the content negotiation.
+// The first MIME type of each format is the official content type of the format.
+var XTemplateMimeTypes = map[string][]string{
+	FormatHTML: {"text/html", "application/xhtml+xml"},
+	FormatJSON: {"application/json", "text/json"},
+	FormatXML:  {"application/xml", "text/xml"},
+	FormatText: {"text/plain"},
+}
+
+// XTemplateSet is a set of templates identified by name and format (html, json, xml, text...), to build the same data in different formats.
+// XTemplateSet IS thread safe
+type XTemplateSet struct {
+	mutex sync.RWMutex
+	// fallback is the ordered list of formats to use when the requested format does not exist for a template
+	fallback  []string
+	templates map[string]map[string]*XTemplate
+}
+
+// NewXTemplateSet will create an empty set of templates, with a fallback on the html and then text formats
+func NewXTemplateSet() *XTemplateSet {
+	return &XTemplateSet{
+		fallback:  []string{FormatHTML, FormatText},
+		templates: map[string]map[string]*XTemplate{},
+	}
+}
+
+// SetFallback will set the ordered list of formats to use when the requested format does not exist for a template. No format disables the fallback
+func (ts *XTemplateSet) SetFallback(formats ...string) {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+	ts.fallback = append([]string{}, formats...)
+}
+
+// GetFallback will return the ordered list of formats to use when the requested format does not exist for a template
+func (ts *XTemplateSet) GetFallback() []string {
+	ts.mutex.RLock()
+	defer ts.mutex.RUnlock()
+	return append([]string{}, ts.fallback...)
+}
+
+// Set will add the template with its name and format into the set. The format is kept by the set, the template is not modified
+// (its Format, used for the JSON output mode and the minification, is set by the caller).
+// If the template already exists, it will be replaced
+func (ts *XTemplateSet) Set(name string, format string, tmpl *XTemplate) {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+	if ts.templates == nil {
+		ts.templates = map[string]map[string]*XTemplate{}
+	}
+	if ts.templates[name] == nil {
+		ts.templates[name] = map[string]*XTemplate{}
+	}
+	ts.templates[name][format] = tmpl
+}
+
+// LoadFile will load the template file into the set. The name and the format are taken from the file name: name.format.template
+// A file without format (name.template) is a text template.
+func (ts *XTemplateSet) LoadFile(file string) error {
+	name := strings.TrimSuffix(filepath.Base(file), ".template")
+	format := FormatText
+	if pos := strings.LastIndex(name, "."); pos >= 0 {
+		format = name[pos+1:]
+		name = name[:pos]
+	}
+	tmpl, err := NewXTemplateFromFile(file)
+	if err != nil {
+		return err
+	}
+	ts.Set(name, format, tmpl)
+	return nil
+}
+
+// LoadDir will load all the *.template files of the directory into the set
+func (ts *XTemplateSet) LoadDir(dir string) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*.template"))
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		if err := ts.LoadFile(file); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Del will remove the template with its name and format from the set
+func (ts *XTemplateSet) Del(name string, format string) {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+	if formats, ok := ts.templates[name]; ok {
+		delete(formats, format)
+		if len(formats) == 0 {
+			delete(ts.templates, name)
+		}
+	}
+}
+
+// Formats will return the sorted list of the available formats of the template name
+func (ts *XTemplateSet) Formats(name string) []string {
+	ts.mutex.RLock()
+	defer ts.mutex.RUnlock()
+	formats := []string{}
+	for format := range ts.templates[name] {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+// Get will return the template name in the requested format, or in the first available fallback format.
+// Returns the template and its format, or false if there is no template available
+func (ts *XTemplateSet) Get(name string, format string) (*XTemplate, string, bool) {
+	ts.mutex.RLock()
+	defer ts.mutex.RUnlock()
+	formats, ok := ts.templates[name]
+	if !ok {
+		return nil, "", false
+	}
+	if tmpl, ok := formats[format]; ok {
+		return tmpl, format, true
+	}
+	for _, fallback := range ts.fallback {
+		if tmpl, ok := formats[fallback]; ok {
+			return tmpl, fallback, true
+		}
+	}
+	return nil, "", false
+}
+
+// Negotiate will return the template name in the best format for the Accept header value (for instance "text/html,application/json;q=0.9,*/*;q=0.8").
+// If no accepted format is available, the fallback formats are used.
+// Returns the template and its format, or false if there is no template available
+func (ts *XTemplateSet) Negotiate(name string, accept string) (*XTemplate, string, bool) {
+	// the fallback formats are preferred for the wildcards
+	formats := ts.Formats(name)
+	exists := map[string]bool{}
+	for _, format := range formats {
+		exists[format] = true
+	}
+	available := []string{}
+	for _, format := range ts.GetFallback() {
+		if exists[format] {
+			available = append(available, format)
+			exists[format] = false
+		}
+	}
+	for _, format := range formats {
+		if exists[format] {
+			available = append(available, format)
+		}
+	}
+	for _, mimetype := range parseAccept(accept) {
+		for _, format := range available {
+			if acceptFormat(mimetype, format) {
+				return ts.Get(name, format)
+			}
+		}
+	}
+	return ts.Get(name, "")
+}
+
+// Execute will inject the data into the template name in the requested format (or a fallback format).
+// Returns the result and the used format
+func (ts *XTemplateSet) Execute(name string, format string, data XDatasetDef) (string, string, error) {
+	return ts.ExecuteContext(context.Background(), name, format, data, nil)
+}
+
+// ExecuteAccept will inject the data into the template name in the best format for the Accept header value.
+// Returns the result and the used format (use ContentType(format) to get the content type to send to the client)
+func (ts *XTemplateSet) ExecuteAccept(name string, accept string, data XDatasetDef) (string, string, error) {
+	tmpl, format, ok := ts.Negotiate(name, accept)
+	if !ok {
+		return "", "", errors.New("Error: template not found: " + name)
+	}
+	str, err := tmpl.ExecuteContext(context.Background(), data, nil)
+	if err != nil {
+		return "", "", err
+	}
+	return str, format, nil
+}
+
+// ExecuteContext is the same as Execute, controlled with a context and limits (see XTemplate.ExecuteContext)
+func (ts *XTemplateSet) ExecuteContext(ctx context.Context, name string, format string, data XDatasetDef, limits *XTemplateLimits) (string, string, error) {
+	tmpl, format, ok := ts.Get(name, format)
+	if !ok {
+		return "", "", errors.New("Error: template not found: " + name)
+	}
+	str, err := tmpl.ExecuteContext(ctx, data, limits)
+	if err != nil {
+		return "", "", err
+	}
+	return str, format, nil
+}
+
+// ContentType will return the MIME content type of the format, with the UTF-8 charset for text formats
+func ContentType(format string) string {
+	mimetypes, ok := XTemplateMimeTypes[format]
+	if !ok || len(mimetypes) == 0 {
+		return "application/octet-stream"
+	}
+	if format == FormatJSON {
+		return mimetypes[0]
+	}
+	return mimetypes[0] + "; charset=utf-8"
+}
+
+// acceptFormat will check if the MIME type (maybe with wildcards) is a type of the format
+func acceptFormat(mimetype string, format string) bool {
+	if mimetype == "*/*" {
+		return true
+	}
+	for _, m := range XTemplateMimeTypes[format] {
+		if m == mimetype {
+			return true
+		}
+		if strings.HasSuffix(mimetype, "/*") && strings.HasPrefix(m, strings.TrimSuffix(mimetype, "*")) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseAccept will return the MIME types of the Accept header value ordered by quality
+func parseAccept(accept string) []string {
+	type mimeq struct {
+		mimetype string
+		q        float64
+	}
+	mimes := []mimeq{}
+	for _, part := range strings.Split(accept, ",") {
+		params := strings.Split(part, ";")
+		mimetype := strings.ToLower(strings.TrimSpace(params[0]))
+		if mimetype == "" {
+			continue
+		}
+		q := 1.0
+		for _, param := range params[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if v, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					q = v
+				}
+			}
+		}
+		if q > 0 {
+			mimes = append(mimes, mimeq{mimetype: mimetype, q: q})
+		}
+	}
+	sort.SliceStable(mimes, func(i, j int) bool { return mimes[i].q > mimes[j].q })
+	result := []string{}
+	for _, m := range mimes {
+		result = append(result, m.mimetype)
+	}
+	return result
+}
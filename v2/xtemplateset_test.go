@@ -0,0 +1,70 @@
+package xcore
+
+import (
+	"testing"
+)
+
+func TestXTemplateSet(t *testing.T) {
+	ts := NewXTemplateSet()
+	err := ts.LoadDir("testunit")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	data := &XDataset{"name": "Fred"}
+
+	tests := map[string]string{
+		FormatHTML: "<h1>Fred</h1>\n",
+		FormatJSON: "{\"name\": \"Fred\"}\n",
+		FormatXML:  "<page><name>Fred</name></page>\n",
+		FormatText: "Name: Fred\n",
+		"pdf":      "<h1>Fred</h1>\n", // fallback
+	}
+	for format, expected := range tests {
+		result, _, err := ts.Execute("page", format, data)
+		if err != nil || result != expected {
+			t.Errorf("Error executing the template page in format %s: %s %v", format, result, err)
+		}
+	}
+
+	accepts := map[string]string{
+		"application/json": FormatJSON,
+		"text/html,application/xhtml+xml;q=0.9,*/*;q=0.8": FormatHTML,
+		"application/json;q=0.5, application/xml":         FormatXML,
+		"text/*":                           FormatHTML,
+		"*/*":                              FormatHTML,
+		"image/png":                        FormatHTML,
+		"text/plain, application/json;q=0": FormatText,
+	}
+	for accept, expected := range accepts {
+		_, format, err := ts.ExecuteAccept("page", accept, data)
+		if err != nil || format != expected {
+			t.Errorf("Error negotiating the template page for %s: %s %v", accept, format, err)
+		}
+	}
+
+	if ContentType(FormatJSON) != "application/json" || ContentType(FormatHTML) != "text/html; charset=utf-8" {
+		t.Error("Error in the content types")
+	}
+
+	// the set keeps the format, the template is not modified
+	tmpl, _ := NewXTemplateFromString("{{name}}")
+	ts.Set("other", FormatJSON, tmpl)
+	if tmpl.Format != "" {
+		t.Errorf("Error: Set modified the format of the template: %s", tmpl.Format)
+	}
+	if fallback := ts.GetFallback(); len(fallback) != 2 || fallback[0] != FormatHTML {
+		t.Errorf("Error in the fallback formats: %v", fallback)
+	}
+
+	ts.Del("page", FormatHTML)
+	ts.SetFallback()
+	_, _, err = ts.Execute("page", FormatHTML, data)
+	if err == nil {
+		t.Error("The html page should not exist anymore")
+	}
+	_, _, err = ts.Execute("unknown", FormatHTML, data)
+	if err == nil {
+		t.Error("The unknown page should not exist")
+	}
+}